@@ -1,9 +1,9 @@
 package build
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -15,11 +15,15 @@ type Package struct {
 	Name       string
 }
 
-func PackageInfo(path string) (Package, error) {
+// PackageInfo describes the package at path. The go command runs in
+// dir, or in the working directory if dir is empty.
+func PackageInfo(dir, path string) (Package, error) {
 	if path == "" {
 		path = "."
 	}
-	out, err := exec.Command("go", "list", "-json", path).Output()
+	cmd := exec.Command("go", "list", "-json", path)
+	cmd.Dir = dir
+	out, err := cmd.Output()
 	if err != nil {
 		return Package{}, fmt.Errorf("run \"go list -json %s\": %w", path, err)
 	}
@@ -30,30 +34,70 @@ func PackageInfo(path string) (Package, error) {
 	return pkg, nil
 }
 
-func Build(path string) (string, error) {
+// ModulePath returns the path of the main module of the directory dir.
+func ModulePath(dir string) (string, error) {
+	cmd := exec.Command("go", "list", "-m")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("run \"go list -m\": %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Build builds the main package at path without optimizations into dir,
+// where the go command runs. flags are passed to go build.
+func Build(dir, path string, flags []string) (string, error) {
 	if path == "" {
 		path = "."
 	}
-	cmd := exec.Command("go", "build", "-o", "godbg.bin", "-gcflags", "-N -l", path)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	args := append([]string{"build", "-o", "godbg.bin", "-gcflags", "-N -l"}, flags...)
+	cmd := exec.Command("go", append(args, path)...)
+	cmd.Dir = dir
+	if err := run(cmd); err != nil {
 		return "", err
 	}
-	return filepath.Abs("godbg.bin")
+	return filepath.Abs(filepath.Join(dir, "godbg.bin"))
 }
 
-func Test(path string) (string, error) {
+// Test builds the test binary of the package at path into dir, where the
+// go command runs. flags are passed to go test.
+func Test(dir, path string, flags []string) (string, error) {
 	if path == "" {
 		path = "."
 	}
-	cmd := exec.Command("go", "test", "-c", "-o", "godbg.test", path, "-args", "-gcflags", "all='-N -l'")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	args := append([]string{"test", "-c", "-o", "godbg.test"}, flags...)
+	cmd := exec.Command("go", append(args, path, "-args", "-gcflags", "all='-N -l'")...)
+	cmd.Dir = dir
+	if err := run(cmd); err != nil {
 		return "", err
 	}
-	return filepath.Abs("godbg.test")
+	return filepath.Abs(filepath.Join(dir, "godbg.test"))
+}
+
+// Error is a failed build with the output of the go command, which holds
+// the compiler errors.
+type Error struct {
+	Err    error
+	Output string
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error() + "\n" + e.Output
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// run runs cmd and returns an *Error with its output if it fails, so
+// compiler errors can be shown while the UI is running.
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return &Error{Err: err, Output: string(bytes.TrimSpace(out))}
+	}
+	return nil
 }
 
 func TestFunctions(testBinPath string, funcExpr string) ([]string, error) {
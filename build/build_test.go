@@ -8,7 +8,7 @@ import (
 )
 
 func TestTest(t *testing.T) {
-	path, err := build.Test("")
+	path, err := build.Test("", "", nil)
 	if err != nil {
 		t.Fatalf("error: %v", err)
 	}
@@ -17,7 +17,7 @@ func TestTest(t *testing.T) {
 }
 
 func TestPackageInfo(t *testing.T) {
-	pkg, err := build.PackageInfo(".")
+	pkg, err := build.PackageInfo("", ".")
 	if err != nil {
 		t.Fatalf("error: %v", err)
 	}
@@ -26,7 +26,7 @@ func TestPackageInfo(t *testing.T) {
 }
 
 func TestTestFunctions(t *testing.T) {
-	path, err := build.Test("")
+	path, err := build.Test("", "", nil)
 	if err != nil {
 		t.Fatalf("error: %v", err)
 	}
@@ -0,0 +1,198 @@
+package dlv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/service/api"
+)
+
+// Backend is the set of operations the UI drives a debug session with. It
+// is implemented by Debugger, which runs Delve in-process, and by Client,
+// which talks to a headless Delve server.
+type Backend interface {
+	Step() error
+	StepIn() error
+	StepOut() error
+	StepInstruction() error
+	NextInstruction() error
+	Continue() error
+	RunTo(file string, line int) error
+	Halt() error
+
+	Variables() ([]api.Variable, error)
+	Eval(expr string) (*api.Variable, error)
+	SetVariable(expr, value string) error
+	Call(expr string) ([]api.Variable, error)
+	ReturnValues() []api.Variable
+	Crash() *Crash
+	Location() (string, int)
+	Disassemble() ([]api.AsmInstruction, error)
+	Registers(floatingPoint bool) ([]api.Register, error)
+	SourceDir() string
+	Output() *Output
+	Input() *Input
+
+	CreateFileBreakpoint(file string, line int) (*api.Breakpoint, error)
+	CreateFunctionBreakpoint(name string) (*api.Breakpoint, error)
+	BreakpointFunction(id int) (string, bool)
+	Functions() ([]string, error)
+	SetBreakpointCondition(id int, cond, hitCond string) error
+	SetBreakpointEnabled(id int, enabled bool) error
+	CreateLogpoint(file string, line int, format string) (*api.Breakpoint, error)
+	LogpointFormat(id int) (string, bool)
+	Logs() *Logs
+	CreateWatchpoint(expr string, wtype api.WatchType) error
+	WatchEvents() []WatchEvent
+	ClearBreakpoint(id int) error
+	Breakpoints() []*api.Breakpoint
+
+	Goroutines() ([]*api.Goroutine, error)
+	GoroutineID() int64
+	SelectGoroutine(id int64) error
+	Stacktrace() ([]api.Stackframe, error)
+	Frame() int
+	SelectFrame(frame int) error
+
+	Exited() bool
+	ExitStatus() int
+	Restart() ([]api.DiscardedBreakpoint, error)
+	ReadOnly() bool
+	Attached() bool
+	Detach(kill bool) error
+	Close() error
+}
+
+var (
+	_ Backend = (*Debugger)(nil)
+	_ Backend = (*Client)(nil)
+)
+
+// maxFrames is the depth up to which stacks are loaded.
+const maxFrames = 50
+
+var loadConfig = proc.LoadConfig{
+	FollowPointers:     true,
+	MaxVariableRecurse: 1,
+	MaxStringLen:       100,
+	MaxArrayValues:     64,
+	MaxStructFields:    -1,
+}
+
+// userFrame returns the index of the topmost frame outside of the runtime.
+func userFrame(frames []api.Stackframe) int {
+	for i, f := range frames {
+		if f.Function != nil && !strings.HasPrefix(f.Function.Name(), "runtime.") && f.File != "?" {
+			return i
+		}
+	}
+	return 0
+}
+
+// frameLocation returns the file and line of the selected frame, falling
+// back to the current thread when the selection points at it.
+func frameLocation(state *api.DebuggerState, goroutineID int64, frame int, stacktrace func(int64, int) ([]api.Stackframe, error)) (string, int) {
+	if state.CurrentThread == nil {
+		return "", 0
+	}
+
+	file, line := state.CurrentThread.File, state.CurrentThread.Line
+	if goroutineID != state.CurrentThread.GoroutineID || frame != 0 {
+		frames, err := stacktrace(goroutineID, frame)
+		if err != nil || len(frames) <= frame {
+			return "", 0
+		}
+		file, line = frames[frame].File, frames[frame].Line
+	}
+
+	if file == "<autogenerated>" || file == "?" {
+		return "", 0
+	}
+	return file, line
+}
+
+// framePC returns the program counter of the selected frame. For callers
+// of the topmost frame it is the return address.
+func framePC(state *api.DebuggerState, goroutineID int64, frame int, stacktrace func(int64, int) ([]api.Stackframe, error)) (uint64, error) {
+	if state.CurrentThread == nil {
+		return 0, errors.New("no thread selected")
+	}
+	if goroutineID == state.CurrentThread.GoroutineID && frame == 0 {
+		return state.CurrentThread.PC, nil
+	}
+	frames, err := stacktrace(goroutineID, frame)
+	if err != nil {
+		return 0, err
+	}
+	if len(frames) <= frame {
+		return 0, fmt.Errorf("no frame %d", frame)
+	}
+	return frames[frame].PC, nil
+}
+
+// markPC sets AtPC on the instruction at pc only, so it marks the PC of
+// the selected frame instead of the one of the current thread.
+func markPC(insts []api.AsmInstruction, pc uint64) {
+	for i := range insts {
+		insts[i].AtPC = insts[i].Loc.PC == pc
+	}
+}
+
+// callReturn returns the values returned by an injected call. If the
+// call did not return because the target stopped elsewhere, stopped is
+// called to select the new location.
+func callReturn(state *api.DebuggerState, stopped func()) ([]api.Variable, error) {
+	if state.Exited {
+		stopped()
+		return nil, errors.New("process exited during the call")
+	}
+	for _, th := range state.Threads {
+		if th.CallReturn {
+			return th.ReturnValues, nil
+		}
+	}
+	stopped()
+	return nil, nil
+}
+
+// returnValues returns the values returned by the function the current
+// thread just stepped out of.
+func returnValues(state *api.DebuggerState) []api.Variable {
+	if state.CurrentThread == nil {
+		return nil
+	}
+	return state.CurrentThread.ReturnValues
+}
+
+func currentGoroutine(state *api.DebuggerState) int64 {
+	if state.CurrentThread == nil {
+		return 0
+	}
+	return state.CurrentThread.GoroutineID
+}
+
+// breakpointAt returns the breakpoint of bps set on file:line, or nil.
+func breakpointAt(bps []*api.Breakpoint, file string, line int) *api.Breakpoint {
+	for _, bp := range bps {
+		if bp.File == file && bp.Line == line {
+			return bp
+		}
+	}
+	return nil
+}
+
+// stopsAlways reports whether bp stops the target every time it is hit.
+func stopsAlways(bp *api.Breakpoint) bool {
+	return !bp.Disabled && bp.Cond == "" && bp.HitCond == "" && !bp.Tracepoint
+}
+
+// unconditional returns a copy of bp that stops every time it is hit. Run
+// to cursor sets it in place of a breakpoint on the line that may not
+// stop, as there can only be one breakpoint per line.
+func unconditional(bp *api.Breakpoint) *api.Breakpoint {
+	amended := *bp
+	amended.Disabled, amended.Cond, amended.HitCond, amended.Tracepoint = false, "", "", false
+	return &amended
+}
@@ -0,0 +1,553 @@
+package dlv_test
+
+import (
+	"net"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-delve/delve/service"
+	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/debugger"
+	"github.com/go-delve/delve/service/rpccommon"
+	"github.com/philippta/godbg/dlv"
+)
+
+// backends start the test program in each backend, stopped before it
+// reaches the code under test.
+var backends = []struct {
+	name  string
+	start func(t *testing.T) dlv.Backend
+}{
+	{"debugger", launch},
+	{"client", connect},
+}
+
+// forEachBackend runs test against each backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, dbg dlv.Backend)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			test(t, b.start(t))
+		})
+	}
+}
+
+// buildCrash builds the test program without optimizations.
+func buildCrash(t *testing.T) string {
+	binpath := filepath.Join(t.TempDir(), "crash")
+	out, err := exec.Command("go", "build", "-gcflags", "all=-N -l", "-o", binpath, "./testdata/crash").CombinedOutput()
+	if err != nil {
+		t.Fatalf("build: %v\n%s", err, out)
+	}
+	return binpath
+}
+
+// launch runs the test program in an in-process debugger, which stops it
+// at main.main.
+func launch(t *testing.T) dlv.Backend {
+	dbg, err := dlv.Exec(buildCrash(t), nil, dlv.Options{SkipGoVersionCheck: true})
+	if err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	t.Cleanup(func() { dbg.Close() })
+	return dbg
+}
+
+// connect starts a headless Delve server on loopback that runs the test
+// program and connects a client to it.
+func connect(t *testing.T) dlv.Backend {
+	binpath := buildCrash(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := rpccommon.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: []string{binpath},
+		APIVersion:  2,
+		Debugger: debugger.Config{
+			Backend:     "default",
+			ExecuteKind: debugger.ExecutingExistingFile,
+		},
+	})
+	if err := server.Run(); err != nil {
+		t.Fatalf("run server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	client, err := dlv.Connect(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Detach(true) })
+	return client
+}
+
+func TestStep(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFunctionBreakpoint("main.process"); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+
+		file, line := dbg.Location()
+		if filepath.Base(file) != "main.go" || line != 10 {
+			t.Errorf("location = %s:%d, want main.go:10", file, line)
+		}
+
+		vars, err := dbg.Variables()
+		if err != nil {
+			t.Fatalf("variables: %v", err)
+		}
+		if len(vars) != 1 || vars[0].Name != "items" {
+			t.Errorf("variables = %v, want [items]", vars)
+		}
+
+		if err := dbg.Step(); err != nil {
+			t.Fatalf("step: %v", err)
+		}
+		if _, line := dbg.Location(); line != 11 {
+			t.Errorf("line after step = %d, want 11", line)
+		}
+	})
+}
+
+func TestBreakpointCondition(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		bp, err := dbg.CreateFileBreakpoint(filepath.Join(testdataDir(t), "main.go"), 13)
+		if err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if bp.Line != 13 {
+			t.Errorf("created breakpoint on line %d, want 13", bp.Line)
+		}
+		id := bp.ID
+		if err := dbg.SetBreakpointCondition(id, "it.Name == \"b\"", ""); err != nil {
+			t.Fatalf("set condition: %v", err)
+		}
+		if err := dbg.SetBreakpointCondition(id, "undefined ==", ""); err == nil {
+			t.Errorf("invalid condition was accepted")
+		}
+
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+		vars, err := dbg.Variables()
+		if err != nil {
+			t.Fatalf("variables: %v", err)
+		}
+		var total string
+		for _, v := range vars {
+			if v.Name == "total" {
+				total = v.Value
+			}
+		}
+		if total != "1" {
+			t.Errorf("total = %q, want 1", total)
+		}
+	})
+}
+
+func TestBreakpointFunction(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		fn, err := dbg.CreateFunctionBreakpoint("main.double")
+		if err != nil {
+			t.Fatalf("create function breakpoint: %v", err)
+		}
+		line, err := dbg.CreateFileBreakpoint(filepath.Join(testdataDir(t), "main.go"), 13)
+		if err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+
+		if name, ok := dbg.BreakpointFunction(fn.ID); !ok || name != "main.double" {
+			t.Errorf("function of breakpoint = %q, %v, want main.double", name, ok)
+		}
+		if name, ok := dbg.BreakpointFunction(line.ID); ok {
+			t.Errorf("line breakpoint has function %q", name)
+		}
+		if err := dbg.ClearBreakpoint(fn.ID); err != nil {
+			t.Fatalf("clear breakpoint: %v", err)
+		}
+		if _, ok := dbg.BreakpointFunction(fn.ID); ok {
+			t.Errorf("cleared breakpoint has a function")
+		}
+	})
+}
+
+func TestBreakpointEnabled(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		file := filepath.Join(testdataDir(t), "main.go")
+
+		for _, line := range []int{13, 15} {
+			if _, err := dbg.CreateFileBreakpoint(file, line); err != nil {
+				t.Fatalf("create breakpoint: %v", err)
+			}
+		}
+		var id int
+		for _, bp := range dbg.Breakpoints() {
+			if bp.Line == 13 {
+				id = bp.ID
+			}
+		}
+		if err := dbg.SetBreakpointEnabled(id, false); err != nil {
+			t.Fatalf("disable breakpoint: %v", err)
+		}
+
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+		if _, line := dbg.Location(); line != 15 {
+			t.Errorf("line = %d, want 15 past the disabled breakpoint", line)
+		}
+		disabled := false
+		for _, bp := range dbg.Breakpoints() {
+			disabled = disabled || bp.ID == id && bp.Disabled
+		}
+		if !disabled {
+			t.Errorf("breakpoint %d is not listed as disabled", id)
+		}
+	})
+}
+
+func TestLogpoint(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateLogpoint(filepath.Join(testdataDir(t), "main.go"), 13, "{it.Name}: total={total}"); err != nil {
+			t.Fatalf("create logpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+
+		// The logpoints do not stop, so the program runs into the panic.
+		want := []string{`"a": total=0`, `"b": total=1`}
+		entries := dbg.Logs().Entries()
+		if len(entries) != len(want) {
+			t.Fatalf("got %d log entries, want %d: %v", len(entries), len(want), entries)
+		}
+		for i := range want {
+			if entries[i].Message != want[i] || entries[i].Line != 13 {
+				t.Errorf("entry %d = %d %q, want 13 %q", i, entries[i].Line, entries[i].Message, want[i])
+			}
+		}
+	})
+}
+
+func TestWatchpoint(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFunctionBreakpoint("main.process"); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+		for range 2 {
+			if err := dbg.Step(); err != nil {
+				t.Fatalf("step: %v", err)
+			}
+		}
+		if err := dbg.CreateWatchpoint("total", api.WatchWrite); err != nil {
+			t.Fatalf("create watchpoint: %v", err)
+		}
+
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+		events := dbg.WatchEvents()
+		if len(events) != 1 {
+			t.Fatalf("got %d watch events, want 1: %v", len(events), events)
+		}
+		if e := events[0]; e.Expr != "total" || e.OldValue != "0" || e.NewValue != "1" || e.Function != "main.process" {
+			t.Errorf("event = %+v, want total 0 -> 1 in main.process", e)
+		}
+	})
+}
+
+func TestSetVariable(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFunctionBreakpoint("main.process"); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+		for range 2 {
+			if err := dbg.Step(); err != nil {
+				t.Fatalf("step: %v", err)
+			}
+		}
+
+		if err := dbg.SetVariable("total", "41"); err != nil {
+			t.Fatalf("set variable: %v", err)
+		}
+		if err := dbg.SetVariable("total", `"a"`); err == nil {
+			t.Errorf("assigning a string to an int was accepted")
+		}
+		v, err := dbg.Eval("total")
+		if err != nil {
+			t.Fatalf("eval: %v", err)
+		}
+		if v.Value != "41" {
+			t.Errorf("total = %s, want 41", v.Value)
+		}
+	})
+}
+
+func TestCall(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFileBreakpoint(filepath.Join(testdataDir(t), "main.go"), 15); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+
+		if _, err := dbg.Call("undefined()"); err == nil {
+			t.Errorf("call of undefined function succeeded")
+		}
+		if _, line := dbg.Location(); line != 15 {
+			t.Errorf("line after failed call = %d, want 15", line)
+		}
+	})
+}
+
+func TestDisassemble(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFileBreakpoint(filepath.Join(testdataDir(t), "main.go"), 15); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+
+		pc := func() uint64 {
+			insts, err := dbg.Disassemble()
+			if err != nil {
+				t.Fatalf("disassemble: %v", err)
+			}
+			for _, inst := range insts {
+				if inst.AtPC {
+					if inst.Loc.Function == nil || inst.Loc.Function.Name() != "main.process" {
+						t.Errorf("instruction at PC in %v, want main.process", inst.Loc.Function)
+					}
+					return inst.Loc.PC
+				}
+			}
+			t.Fatalf("no instruction at PC in %d instructions", len(insts))
+			return 0
+		}
+
+		before := pc()
+		if err := dbg.NextInstruction(); err != nil {
+			t.Fatalf("next instruction: %v", err)
+		}
+		if after := pc(); after <= before {
+			t.Errorf("PC after next instruction = %#x, want after %#x", after, before)
+		}
+		if _, line := dbg.Location(); line != 15 {
+			t.Errorf("line after next instruction = %d, want 15", line)
+		}
+	})
+}
+
+func TestRegisters(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFunctionBreakpoint("main.process"); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+
+		regs, err := dbg.Registers(false)
+		if err != nil {
+			t.Fatalf("registers: %v", err)
+		}
+		if len(regs) == 0 {
+			t.Fatalf("no registers")
+		}
+		all, err := dbg.Registers(true)
+		if err != nil {
+			t.Fatalf("registers with floating point: %v", err)
+		}
+		if len(all) <= len(regs) {
+			t.Errorf("%d registers with floating point, want more than %d", len(all), len(regs))
+		}
+	})
+}
+
+func TestReturnValues(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFunctionBreakpoint("main.double"); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+		if err := dbg.StepOut(); err != nil {
+			t.Fatalf("step out: %v", err)
+		}
+
+		vals := dbg.ReturnValues()
+		if len(vals) != 1 || vals[0].Value != "2" {
+			t.Errorf("return values = %v, want [2]", vals)
+		}
+
+		if err := dbg.Step(); err != nil {
+			t.Fatalf("step: %v", err)
+		}
+		if vals := dbg.ReturnValues(); len(vals) != 0 {
+			t.Errorf("return values after step = %v, want none", vals)
+		}
+	})
+}
+
+func TestCrash(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if err := dbg.Continue(); err != nil {
+			t.Fatalf("continue: %v", err)
+		}
+
+		crash := dbg.Crash()
+		if crash == nil {
+			t.Fatalf("no crash after continue")
+		}
+		if crash.Fatal {
+			t.Errorf("panic reported as fatal error")
+		}
+		if v := crash.Value; len(v.Children) != 1 || v.Children[0].Value != "assignment to entry in nil map" {
+			t.Errorf("panic value = %+v, want assignment to entry in nil map", v)
+		}
+		if _, line := dbg.Location(); line != 18 {
+			t.Errorf("crash line = %d, want 18", line)
+		}
+	})
+}
+
+func TestRunTo(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		file := filepath.Join(testdataDir(t), "main.go")
+
+		if err := dbg.RunTo(file, 15); err != nil {
+			t.Fatalf("run to line 15: %v", err)
+		}
+		if _, line := dbg.Location(); line != 15 {
+			t.Errorf("line = %d, want 15", line)
+		}
+
+		// Line 13 is not reached again, so the target stops at the crash.
+		if err := dbg.RunTo(file, 13); err != nil {
+			t.Fatalf("run to line 13: %v", err)
+		}
+		if dbg.Crash() == nil {
+			t.Errorf("want a crash stop")
+		}
+		for _, bp := range dbg.Breakpoints() {
+			if bp.File == file && (bp.Line == 13 || bp.Line == 15) {
+				t.Errorf("temporary breakpoint left at line %d", bp.Line)
+			}
+		}
+	})
+}
+
+func TestRunToBreakpoint(t *testing.T) {
+	// Each breakpoint on line 13 would not stop the first time it is hit.
+	tests := map[string]func(dbg dlv.Backend, file string, id int) error{
+		"disabled": func(dbg dlv.Backend, file string, id int) error {
+			return dbg.SetBreakpointEnabled(id, false)
+		},
+		"condition": func(dbg dlv.Backend, file string, id int) error {
+			return dbg.SetBreakpointCondition(id, "false", "")
+		},
+		"hit condition": func(dbg dlv.Backend, file string, id int) error {
+			return dbg.SetBreakpointCondition(id, "", "> 5")
+		},
+		"logpoint": func(dbg dlv.Backend, file string, id int) error {
+			if err := dbg.ClearBreakpoint(id); err != nil {
+				return err
+			}
+			_, err := dbg.CreateLogpoint(file, 13, "{total}")
+			return err
+		},
+	}
+	for name, setup := range tests {
+		t.Run(name, func(t *testing.T) {
+			forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+				file := filepath.Join(testdataDir(t), "main.go")
+				if _, err := dbg.CreateFileBreakpoint(file, 13); err != nil {
+					t.Fatalf("create breakpoint: %v", err)
+				}
+				if err := setup(dbg, file, lineBreakpoint(t, dbg, 13).ID); err != nil {
+					t.Fatalf("setup: %v", err)
+				}
+				before := *lineBreakpoint(t, dbg, 13)
+
+				if err := dbg.RunTo(file, 13); err != nil {
+					t.Fatalf("run to line 13: %v", err)
+				}
+				if _, line := dbg.Location(); line != 13 {
+					t.Errorf("line = %d, want 13", line)
+				}
+				after := lineBreakpoint(t, dbg, 13)
+				if after.Disabled != before.Disabled || after.Cond != before.Cond || after.HitCond != before.HitCond || after.Tracepoint != before.Tracepoint {
+					t.Errorf("breakpoint after run = %+v, want %+v", after, before)
+				}
+			})
+		})
+	}
+}
+
+// lineBreakpoint returns the breakpoint on line of the test program.
+func lineBreakpoint(t *testing.T, dbg dlv.Backend, line int) *api.Breakpoint {
+	for _, bp := range dbg.Breakpoints() {
+		if bp.Line == line && filepath.Base(bp.File) == "main.go" {
+			return bp
+		}
+	}
+	t.Fatalf("no breakpoint on line %d", line)
+	return nil
+}
+
+func TestRestart(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dbg dlv.Backend) {
+		if _, err := dbg.CreateFileBreakpoint(filepath.Join(testdataDir(t), "main.go"), 13); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+		for dbg.Crash() == nil {
+			if err := dbg.Continue(); err != nil {
+				t.Fatalf("continue: %v", err)
+			}
+		}
+
+		discarded, err := dbg.Restart()
+		if err != nil {
+			t.Fatalf("restart: %v", err)
+		}
+		if len(discarded) != 0 {
+			t.Errorf("discarded breakpoints = %v, want none", discarded)
+		}
+		// Both backends run to the breakpoints, where the in-process
+		// debugger stops at main.main first.
+		if file, _ := dbg.Location(); filepath.Base(file) != "main.go" {
+			t.Errorf("file after restart = %s, want main.go", file)
+		}
+		if _, line := dbg.Location(); line != 13 {
+			if err := dbg.Continue(); err != nil {
+				t.Fatalf("continue: %v", err)
+			}
+		}
+		if _, line := dbg.Location(); line != 13 {
+			t.Errorf("line after restart = %d, want 13", line)
+		}
+	})
+}
+
+func testdataDir(t *testing.T) string {
+	dir, err := filepath.Abs("testdata/crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
@@ -0,0 +1,470 @@
+package dlv
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/rpc2"
+)
+
+// Client drives a headless Delve server over its JSON-RPC API.
+type Client struct {
+	rpc      *rpc2.RPCClient
+	state    *api.DebuggerState
+	output   Output
+	input    Input
+	detached bool
+
+	goroutineID int64
+	frame       int
+
+	logs    Logs
+	formats map[int]*logFormat
+	// funcBreakpoints are the functions breakpoints were created on.
+	funcBreakpoints map[int]string
+
+	watches     map[int]*watch
+	watchEvents []WatchEvent
+
+	returnValues []api.Variable
+	crash        *Crash
+}
+
+func Connect(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", addr, err)
+	}
+
+	rpc := rpc2.NewClientFromConn(conn)
+	rpc.SetReturnValuesLoadConfig(api.LoadConfigFromProc(&loadConfig))
+	state, err := rpc.GetState()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("debugger state: %w", err)
+	}
+
+	c := &Client{rpc: rpc, state: state}
+	createCrashBreakpoints(c.Breakpoints(), c.createBreakpoint)
+	c.selectCurrent()
+
+	return c, nil
+}
+
+func (c *Client) Step() error {
+	return c.command(c.rpc.Next())
+}
+
+func (c *Client) StepIn() error {
+	return c.command(c.rpc.Step())
+}
+
+func (c *Client) StepOut() error {
+	return c.command(c.rpc.StepOut())
+}
+
+func (c *Client) StepInstruction() error {
+	return c.command(c.rpc.StepInstruction(false))
+}
+
+func (c *Client) NextInstruction() error {
+	return c.command(c.rpc.StepInstruction(true))
+}
+
+// Continue resumes the target until it stops at a breakpoint. The client
+// resumes the target after tracepoints by itself and reports every stop.
+func (c *Client) Continue() error {
+	var state *api.DebuggerState
+	for state = range c.rpc.Continue() {
+		if onlyLogpoints(state, c.formats) {
+			recordLogpoints(state, c.formats, &c.logs)
+		}
+	}
+	if state.Exited {
+		// The client reports an exited process as error, the local
+		// debugger does not.
+		state.Err = nil
+	}
+	return c.command(state, state.Err)
+}
+
+func (c *Client) Halt() error {
+	_, err := c.rpc.Halt()
+	return err
+}
+
+func (c *Client) command(state *api.DebuggerState, err error) error {
+	c.returnValues, c.crash = nil, nil
+	if err != nil {
+		return err
+	}
+	c.state = state
+	c.selectCurrent()
+	c.returnValues = returnValues(state)
+	c.crash = crashAt(state, func(expr string) (*api.Variable, error) {
+		return c.eval(api.EvalScope{GoroutineID: c.goroutineID}, expr)
+	})
+	if c.crash != nil {
+		// Show the user code that crashed rather than the runtime.
+		frames, _ := c.stacktrace(c.goroutineID, maxFrames)
+		c.frame = userFrame(frames)
+	}
+	recordLogpoints(state, c.formats, &c.logs)
+	c.watchEvents = watchEvents(state, c.watches, func(expr string) (*api.Variable, error) {
+		return c.eval(api.EvalScope{GoroutineID: -1}, expr)
+	})
+	return nil
+}
+
+func (c *Client) Variables() ([]api.Variable, error) {
+	if c.state.CurrentThread == nil {
+		return []api.Variable{}, nil
+	}
+
+	scope := api.EvalScope{GoroutineID: c.goroutineID, Frame: c.frame}
+	cfg := *api.LoadConfigFromProc(&loadConfig)
+
+	args, err := c.rpc.ListFunctionArgs(scope, cfg)
+	if err != nil {
+		return nil, err
+	}
+	locals, err := c.rpc.ListLocalVariables(scope, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(args, locals...), nil
+}
+
+func (c *Client) Eval(expr string) (*api.Variable, error) {
+	return c.eval(api.EvalScope{GoroutineID: c.goroutineID, Frame: c.frame}, expr)
+}
+
+func (c *Client) eval(scope api.EvalScope, expr string) (*api.Variable, error) {
+	return c.rpc.EvalVariable(scope, expr, *api.LoadConfigFromProc(&loadConfig))
+}
+
+func (c *Client) Call(expr string) ([]api.Variable, error) {
+	c.returnValues = nil
+	state, err := c.rpc.Call(c.goroutineID, expr, false)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", expr, err)
+	}
+	return callReturn(state, func() {
+		c.state = state
+		c.selectCurrent()
+	})
+}
+
+func (c *Client) ReturnValues() []api.Variable {
+	return c.returnValues
+}
+
+func (c *Client) Crash() *Crash {
+	return c.crash
+}
+
+func (c *Client) SetVariable(expr, value string) error {
+	return c.rpc.SetVariable(api.EvalScope{GoroutineID: c.goroutineID, Frame: c.frame}, expr, value)
+}
+
+func (c *Client) CreateFileBreakpoint(file string, line int) (*api.Breakpoint, error) {
+	return c.rpc.CreateBreakpoint(&api.Breakpoint{File: file, Line: line})
+}
+
+// RunTo continues the target to file:line through a temporary breakpoint,
+// which is cleared once the target stops, at the line or elsewhere. A
+// breakpoint already set on the line is amended to stop instead.
+func (c *Client) RunTo(file string, line int) error {
+	bp := breakpointAt(c.Breakpoints(), file, line)
+	switch {
+	case bp == nil:
+		tmp, err := c.rpc.CreateBreakpoint(&api.Breakpoint{File: file, Line: line})
+		if err != nil {
+			return err
+		}
+		err = c.Continue()
+		c.rpc.ClearBreakpoint(tmp.ID)
+		return err
+	case stopsAlways(bp):
+		return c.Continue()
+	}
+
+	// The breakpoint on the line stops unconditionally until the run
+	// ends, even if it is a logpoint.
+	if err := c.rpc.AmendBreakpoint(unconditional(bp)); err != nil {
+		return err
+	}
+	f, ok := c.formats[bp.ID]
+	delete(c.formats, bp.ID)
+	err := c.Continue()
+	if ok {
+		c.formats[bp.ID] = f
+	}
+	if amendErr := c.rpc.AmendBreakpoint(bp); err == nil {
+		err = amendErr
+	}
+	return err
+}
+
+func (c *Client) CreateFunctionBreakpoint(name string) (*api.Breakpoint, error) {
+	bp, err := c.rpc.CreateBreakpoint(&api.Breakpoint{FunctionName: name})
+	if err != nil {
+		return nil, err
+	}
+	if c.funcBreakpoints == nil {
+		c.funcBreakpoints = map[int]string{}
+	}
+	c.funcBreakpoints[bp.ID] = name
+	return bp, nil
+}
+
+func (c *Client) BreakpointFunction(id int) (string, bool) {
+	name, ok := c.funcBreakpoints[id]
+	return name, ok
+}
+
+// Functions returns the names of all functions in the binary.
+func (c *Client) Functions() ([]string, error) {
+	return c.rpc.ListFunctions("", 0)
+}
+
+func (c *Client) createBreakpoint(bp *api.Breakpoint) error {
+	_, err := c.rpc.CreateBreakpoint(bp)
+	return err
+}
+
+func (c *Client) SetBreakpointCondition(id int, cond, hitCond string) error {
+	bp, err := c.rpc.GetBreakpoint(id)
+	if err != nil {
+		return err
+	}
+	bp.Cond, bp.HitCond = cond, hitCond
+	return c.rpc.AmendBreakpoint(bp)
+}
+
+func (c *Client) SetBreakpointEnabled(id int, enabled bool) error {
+	bp, err := c.rpc.GetBreakpoint(id)
+	if err != nil {
+		return err
+	}
+	bp.Disabled = !enabled
+	return c.rpc.AmendBreakpoint(bp)
+}
+
+func (c *Client) CreateLogpoint(file string, line int, format string) (*api.Breakpoint, error) {
+	f, err := parseLogFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	bp, err := c.rpc.CreateBreakpoint(&api.Breakpoint{File: file, Line: line, Tracepoint: true, Variables: f.exprs})
+	if err != nil {
+		return nil, err
+	}
+	if c.formats == nil {
+		c.formats = map[int]*logFormat{}
+	}
+	c.formats[bp.ID] = f
+	return bp, nil
+}
+
+func (c *Client) LogpointFormat(id int) (string, bool) {
+	f, ok := c.formats[id]
+	if !ok {
+		return "", false
+	}
+	return f.source, true
+}
+
+func (c *Client) CreateWatchpoint(expr string, wtype api.WatchType) error {
+	v, err := c.Eval(expr)
+	if err != nil {
+		return err
+	}
+	bp, err := c.rpc.CreateWatchpoint(api.EvalScope{GoroutineID: c.goroutineID, Frame: c.frame}, expr, wtype)
+	if err != nil {
+		return err
+	}
+	if c.watches == nil {
+		c.watches = map[int]*watch{}
+	}
+	c.watches[bp.ID] = newWatch(expr, v)
+	return nil
+}
+
+func (c *Client) WatchEvents() []WatchEvent {
+	return c.watchEvents
+}
+
+func (c *Client) Logs() *Logs {
+	return &c.logs
+}
+
+func (c *Client) ClearBreakpoint(id int) error {
+	_, err := c.rpc.ClearBreakpoint(id)
+	delete(c.formats, id)
+	delete(c.funcBreakpoints, id)
+	delete(c.watches, id)
+	return err
+}
+
+func (c *Client) Breakpoints() []*api.Breakpoint {
+	bps, _ := c.rpc.ListBreakpoints(false)
+	return bps
+}
+
+func (c *Client) Exited() bool {
+	return c.state.Exited
+}
+
+func (c *Client) ExitStatus() int {
+	return c.state.ExitStatus
+}
+
+// Restart relaunches the target on the server and runs it to the
+// breakpoints again. Watchpoints belong to the old process and are
+// cleared. The breakpoints that could not be set again are returned.
+func (c *Client) Restart() ([]api.DiscardedBreakpoint, error) {
+	for id := range c.watches {
+		c.ClearBreakpoint(id)
+	}
+	discarded, err := c.rpc.Restart(false)
+	if err != nil {
+		return nil, fmt.Errorf("restart: %w", err)
+	}
+	for _, bp := range discarded {
+		delete(c.formats, bp.Breakpoint.ID)
+		delete(c.funcBreakpoints, bp.Breakpoint.ID)
+	}
+
+	c.state = &api.DebuggerState{}
+	c.selectCurrent()
+	return discarded, c.Continue()
+}
+
+func (c *Client) Location() (string, int) {
+	return frameLocation(c.state, c.goroutineID, c.frame, c.stacktrace)
+}
+
+func (c *Client) Disassemble() ([]api.AsmInstruction, error) {
+	pc, err := framePC(c.state, c.goroutineID, c.frame, c.stacktrace)
+	if err != nil {
+		return nil, fmt.Errorf("disassemble: %w", err)
+	}
+	insts, err := c.rpc.DisassemblePC(api.EvalScope{GoroutineID: c.goroutineID, Frame: c.frame}, pc, api.GoFlavour)
+	if err != nil {
+		return nil, fmt.Errorf("disassemble: %w", err)
+	}
+	markPC(insts, pc)
+	return insts, nil
+}
+
+func (c *Client) Registers(floatingPoint bool) ([]api.Register, error) {
+	regs, err := c.rpc.ListScopeRegisters(api.EvalScope{GoroutineID: c.goroutineID, Frame: c.frame}, floatingPoint)
+	if err != nil {
+		return nil, fmt.Errorf("registers: %w", err)
+	}
+	return regs, nil
+}
+
+func (c *Client) SourceDir() string {
+	locs, _, err := c.rpc.FindLocation(api.EvalScope{GoroutineID: -1}, "main.main", false, nil)
+	if err != nil || len(locs) == 0 || locs[0].File == "" {
+		return "."
+	}
+	return filepath.Dir(locs[0].File)
+}
+
+// Output is always empty for remote sessions, the target writes to the
+// server's terminal.
+func (c *Client) Output() *Output {
+	return &c.output
+}
+
+// Input is never available for remote sessions.
+func (c *Client) Input() *Input {
+	return &c.input
+}
+
+func (c *Client) ReadOnly() bool {
+	return false
+}
+
+// Attached is always true for remote sessions, as the target was started
+// by the server and can outlive the client.
+func (c *Client) Attached() bool {
+	return true
+}
+
+func (c *Client) Goroutines() ([]*api.Goroutine, error) {
+	gs, _, err := c.rpc.ListGoroutines(0, 0)
+	return gs, err
+}
+
+func (c *Client) GoroutineID() int64 {
+	return c.goroutineID
+}
+
+func (c *Client) SelectGoroutine(id int64) error {
+	frames, err := c.stacktrace(id, maxFrames)
+	if err != nil {
+		return err
+	}
+	state, err := c.rpc.SwitchGoroutine(id)
+	if err != nil {
+		return fmt.Errorf("switch goroutine: %w", err)
+	}
+	c.state = state
+	c.goroutineID = id
+	c.frame = userFrame(frames)
+	return nil
+}
+
+func (c *Client) Stacktrace() ([]api.Stackframe, error) {
+	return c.stacktrace(c.goroutineID, maxFrames)
+}
+
+func (c *Client) Frame() int {
+	return c.frame
+}
+
+func (c *Client) SelectFrame(frame int) error {
+	frames, err := c.Stacktrace()
+	if err != nil {
+		return err
+	}
+	if frame < 0 || frame >= len(frames) {
+		return fmt.Errorf("no frame %d", frame)
+	}
+	c.frame = frame
+	return nil
+}
+
+func (c *Client) selectCurrent() {
+	c.goroutineID, c.frame = currentGoroutine(c.state), 0
+}
+
+func (c *Client) stacktrace(goroutineID int64, depth int) ([]api.Stackframe, error) {
+	return c.rpc.Stacktrace(goroutineID, depth, 0, nil)
+}
+
+// Detach detaches the server from the target, which shuts the server
+// down, and optionally kills the target. Subsequent calls are no-ops.
+func (c *Client) Detach(kill bool) error {
+	if c.detached {
+		return nil
+	}
+	c.detached = true
+	return c.rpc.Detach(kill)
+}
+
+// Close disconnects from the server and leaves the target running.
+func (c *Client) Close() error {
+	if c.detached {
+		return nil
+	}
+	c.detached = true
+	return c.rpc.Disconnect(false)
+}
@@ -0,0 +1,68 @@
+package dlv
+
+import (
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/service/api"
+)
+
+// Crash describes an unrecovered panic or a fatal error the target
+// stopped at before dying.
+type Crash struct {
+	// Fatal is set for fatal errors like concurrent map writes, which
+	// cannot be recovered, as opposed to panics.
+	Fatal bool
+	// Value is the panic value or the message of the fatal error.
+	Value api.Variable
+}
+
+// crashBreakpoints are the breakpoints Delve stops at before the target
+// dies and the functions they are set on.
+var crashBreakpoints = []struct{ name, function string }{
+	{proc.UnrecoveredPanic, "runtime.fatalpanic"},
+	{proc.FatalThrow, "runtime.throw"},
+}
+
+// createCrashBreakpoints creates the crash breakpoints missing from bps.
+// Delve sets them when it starts a target, but they can be cleared on a
+// headless server. Targets without the runtime functions are left alone.
+func createCrashBreakpoints(bps []*api.Breakpoint, create func(*api.Breakpoint) error) {
+	for _, cb := range crashBreakpoints {
+		found := false
+		for _, bp := range bps {
+			found = found || bp.Name == cb.name
+		}
+		if !found {
+			create(&api.Breakpoint{Name: cb.name, FunctionName: cb.function})
+		}
+	}
+}
+
+// crashAt returns the crash the current thread stopped at, or nil if it
+// stopped for another reason. eval evaluates in the topmost frame of the
+// crashing goroutine.
+func crashAt(state *api.DebuggerState, eval func(expr string) (*api.Variable, error)) *Crash {
+	th := state.CurrentThread
+	if th == nil || th.Breakpoint == nil {
+		return nil
+	}
+
+	var crash Crash
+	var expr string
+	switch th.Breakpoint.Name {
+	case proc.UnrecoveredPanic:
+		expr = "runtime.curg._panic.arg"
+	case proc.FatalThrow:
+		crash.Fatal = true
+		expr = "s"
+	default:
+		return nil
+	}
+
+	v, err := eval(expr)
+	if err != nil {
+		crash.Value = api.Variable{Unreadable: err.Error()}
+	} else {
+		crash.Value = *v
+	}
+	return &crash
+}
@@ -2,7 +2,10 @@ package dlv
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/go-delve/delve/pkg/proc"
 	"github.com/go-delve/delve/service/api"
@@ -10,18 +13,95 @@ import (
 	"github.com/philippta/godbg/build"
 )
 
+// Options configures how a target is launched.
+type Options struct {
+	// Stdin is the path of a file to read the stdin of the target from.
+	// If empty, input can be sent interactively through Input.
+	Stdin string
+	// Env are key=value pairs added to the environment of the target.
+	// Delve launches targets with the environment of godbg, so they are
+	// set in this process, and apply to builds as well.
+	Env []string
+	// Dir is the working directory of the target. It defaults to the
+	// directory of the package or binary.
+	Dir string
+	// BuildFlags are passed to the go command building the target.
+	BuildFlags []string
+	// BuildDir is the directory the go command runs in. Relative package
+	// paths are resolved against it, and the binary is written to it. It
+	// defaults to the working directory of godbg.
+	BuildDir string
+	// SkipGoVersionCheck lets Delve debug targets built with Go versions
+	// it does not support yet.
+	SkipGoVersionCheck bool
+	// Init is called before the target starts running, to set the
+	// breakpoints of the user. Only these are hit in init functions and
+	// on the first lines of main or a test.
+	Init func(*Debugger)
+}
+
+// setEnv adds the environment variables of opts to the environment.
+func (opts Options) setEnv() error {
+	for _, kv := range opts.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		if err := os.Setenv(k, v); err != nil {
+			return fmt.Errorf("set environment variable %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// workingDir returns the working directory of a target at path.
+func (opts Options) workingDir(path string) string {
+	if opts.Dir != "" {
+		return opts.Dir
+	}
+	dir := filepath.Dir(path)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(opts.BuildDir, dir)
+	}
+	return dir
+}
+
 type Debugger struct {
-	dbg   *debugger.Debugger
-	state *api.DebuggerState
+	dbg      *debugger.Debugger
+	state    *api.DebuggerState
+	output   *Output
+	input    *Input
+	attached bool
+	detached bool
+	core     bool
+
+	goroutineID int64
+	frame       int
+
+	logs    Logs
+	formats map[int]*logFormat
+	// funcBreakpoints are the functions breakpoints were created on.
+	funcBreakpoints map[int]string
+
+	watches     map[int]*watch
+	watchEvents []WatchEvent
+
+	returnValues []api.Variable
+	crash        *Crash
+
+	// rebuild builds the target again before a restart. It is nil for
+	// binaries the debugger did not build.
+	rebuild func() error
+	sources sources
 }
 
-func Test(path string, funcExpr string) (*Debugger, error) {
-	binpath, err := build.Test(path)
+func Test(path string, funcExpr string, args []string, opts Options) (*Debugger, error) {
+	if err := opts.setEnv(); err != nil {
+		return nil, err
+	}
+	binpath, err := build.Test(opts.BuildDir, path, opts.BuildFlags)
 	if err != nil {
 		return nil, fmt.Errorf("build test executable: %w", err)
 	}
 
-	pkg, err := build.PackageInfo(path)
+	pkg, err := build.PackageInfo(opts.BuildDir, path)
 	if err != nil {
 		return nil, fmt.Errorf("package info: %w", err)
 	}
@@ -32,42 +112,54 @@ func Test(path string, funcExpr string) (*Debugger, error) {
 	}
 
 	cfg := &debugger.Config{
-		WorkingDir:     filepath.Dir(path),
+		WorkingDir:     opts.workingDir(path),
 		Backend:        "default",
 		ExecuteKind:    debugger.ExecutingGeneratedTest,
-		CheckGoVersion: true,
-		Stdout: proc.OutputRedirect{
-			Path: "/dev/null",
-		},
-		Stderr: proc.OutputRedirect{
-			Path: "/dev/null",
-		},
+		CheckGoVersion: !opts.SkipGoVersionCheck,
+	}
+	output, input, err := redirect(cfg, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	processArgs := []string{binpath}
+	processArgs := []string{binpath, "-test.v"}
 	if funcExpr != "" {
 		processArgs = append(processArgs, "-test.run", funcExpr)
 	}
+	processArgs = append(processArgs, args...)
 	dbg, err := debugger.New(cfg, processArgs)
 	if err != nil {
+		output.Close()
+		input.Close()
 		return nil, fmt.Errorf("start debugger :%w", err)
 	}
 
-	d := &Debugger{dbg: dbg, state: &api.DebuggerState{}}
+	d := &Debugger{dbg: dbg, state: &api.DebuggerState{}, output: output, input: input}
+	d.rebuild = func() error {
+		_, err := build.Test(opts.BuildDir, path, opts.BuildFlags)
+		return err
+	}
+	createCrashBreakpoints(d.Breakpoints(), d.createBreakpoint)
 	for _, f := range funcs {
-		if err := d.CreateFunctionBreakpoint(pkg.ImportPath + "." + f); err != nil {
-			if err := d.CreateFunctionBreakpoint(pkg.ImportPath + "_test." + f); err != nil {
+		if _, err := d.CreateFunctionBreakpoint(pkg.ImportPath + "." + f); err != nil {
+			if _, err := d.CreateFunctionBreakpoint(pkg.ImportPath + "_test." + f); err != nil {
 				panic(err)
 			}
 		}
 	}
+	if opts.Init != nil {
+		opts.Init(d)
+	}
 	d.Continue()
 
 	return d, nil
 }
 
-func Build(path string, args []string) (*Debugger, error) {
-	pkg, err := build.PackageInfo(path)
+func Build(path string, args []string, opts Options) (*Debugger, error) {
+	if err := opts.setEnv(); err != nil {
+		return nil, err
+	}
+	pkg, err := build.PackageInfo(opts.BuildDir, path)
 	if err != nil {
 		return nil, fmt.Errorf("package info: %w", err)
 	}
@@ -75,119 +167,238 @@ func Build(path string, args []string) (*Debugger, error) {
 		return nil, fmt.Errorf("package is not main")
 	}
 
-	binpath, err := build.Build(path)
+	binpath, err := build.Build(opts.BuildDir, path, opts.BuildFlags)
 	if err != nil {
 		return nil, fmt.Errorf("build executable: %w", err)
 	}
 
 	cfg := &debugger.Config{
-		WorkingDir:     filepath.Dir(path),
+		WorkingDir:     opts.workingDir(path),
 		Backend:        "default",
 		ExecuteKind:    debugger.ExecutingGeneratedFile,
-		CheckGoVersion: true,
-		Stdout: proc.OutputRedirect{
-			Path: "/dev/null",
-		},
-		Stderr: proc.OutputRedirect{
-			Path: "/dev/null",
-		},
+		CheckGoVersion: !opts.SkipGoVersionCheck,
+	}
+	output, input, err := redirect(cfg, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	processArgs := []string{binpath}
 	processArgs = append(processArgs, args...)
 	dbg, err := debugger.New(cfg, processArgs)
 	if err != nil {
+		output.Close()
+		input.Close()
 		return nil, fmt.Errorf("start debugger :%w", err)
 	}
 
-	d := &Debugger{dbg: dbg, state: &api.DebuggerState{}}
-	if err := d.CreateFunctionBreakpoint("main.main"); err != nil {
+	d := &Debugger{dbg: dbg, state: &api.DebuggerState{}, output: output, input: input}
+	d.rebuild = func() error {
+		_, err := build.Build(opts.BuildDir, path, opts.BuildFlags)
+		return err
+	}
+	createCrashBreakpoints(d.Breakpoints(), d.createBreakpoint)
+	if _, err := d.CreateFunctionBreakpoint("main.main"); err != nil {
 		panic(err)
 	}
+	if opts.Init != nil {
+		opts.Init(d)
+	}
 	d.Continue()
 
 	return d, nil
 }
 
-func Exec(program string) (*Debugger, error) {
+func Exec(program string, args []string, opts Options) (*Debugger, error) {
+	if err := opts.setEnv(); err != nil {
+		return nil, err
+	}
 	cfg := &debugger.Config{
-		WorkingDir:     filepath.Dir(program),
+		WorkingDir:     opts.workingDir(program),
 		Backend:        "default",
 		ExecuteKind:    debugger.ExecutingExistingFile,
-		CheckGoVersion: true,
-		Stdout: proc.OutputRedirect{
-			Path: "/dev/null",
-		},
-		Stderr: proc.OutputRedirect{
-			Path: "/dev/null",
-		},
+		CheckGoVersion: !opts.SkipGoVersionCheck,
+	}
+	output, input, err := redirect(cfg, opts)
+	if err != nil {
+		return nil, err
 	}
-	dbg, err := debugger.New(cfg, []string{program})
+	dbg, err := debugger.New(cfg, append([]string{program}, args...))
 	if err != nil {
+		output.Close()
+		input.Close()
 		return nil, fmt.Errorf("start debugger :%w", err)
 	}
 
-	d := &Debugger{dbg: dbg, state: &api.DebuggerState{}}
+	d := &Debugger{dbg: dbg, state: &api.DebuggerState{}, output: output, input: input}
+	createCrashBreakpoints(d.Breakpoints(), d.createBreakpoint)
 	d.CreateFunctionBreakpoint("main.main")
+	if opts.Init != nil {
+		opts.Init(d)
+	}
 	d.Continue()
 
 	return d, nil
 }
 
-func (d *Debugger) Step() error {
-	state, err := d.dbg.Command(&api.DebuggerCommand{Name: api.Next}, nil, nil)
+// redirect connects the stdio of a launched target to the debugger.
+func redirect(cfg *debugger.Config, opts Options) (*Output, *Input, error) {
+	output, err := newOutput()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	d.state = state
-	return nil
+	cfg.Stdout, cfg.Stderr = output.Redirects()
+
+	if opts.Stdin != "" {
+		stdin, err := filepath.Abs(opts.Stdin)
+		if err != nil {
+			output.Close()
+			return nil, nil, fmt.Errorf("stdin path: %w", err)
+		}
+		cfg.Stdin = stdin
+		return output, &Input{}, nil
+	}
+
+	input, err := newInput()
+	if err != nil {
+		output.Close()
+		return nil, nil, err
+	}
+	cfg.Stdin = input.Path()
+	return output, input, nil
 }
 
-func (d *Debugger) StepIn() error {
-	state, err := d.dbg.Command(&api.DebuggerCommand{Name: api.Step}, nil, nil)
+func Attach(pid int) (*Debugger, error) {
+	cfg := &debugger.Config{
+		AttachPid:      pid,
+		Backend:        "default",
+		CheckGoVersion: true,
+	}
+	dbg, err := debugger.New(cfg, nil)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("attach debugger: %w", err)
 	}
-	d.state = state
-	return nil
+
+	state, err := dbg.State(false)
+	if err != nil {
+		dbg.Detach(false)
+		return nil, fmt.Errorf("debugger state: %w", err)
+	}
+
+	d := &Debugger{dbg: dbg, state: state, output: &Output{}, input: &Input{}, attached: true}
+	createCrashBreakpoints(d.Breakpoints(), d.createBreakpoint)
+	d.selectCurrent()
+
+	return d, nil
 }
 
-func (d *Debugger) StepOut() error {
-	state, err := d.dbg.Command(&api.DebuggerCommand{Name: api.StepOut}, nil, nil)
+func Core(binary, corefile string) (*Debugger, error) {
+	cfg := &debugger.Config{
+		CoreFile:       corefile,
+		Backend:        "default",
+		CheckGoVersion: true,
+	}
+	dbg, err := debugger.New(cfg, []string{binary})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("open core file: %w", err)
 	}
-	d.state = state
-	return nil
+
+	state, err := dbg.State(false)
+	if err != nil {
+		dbg.Detach(true)
+		return nil, fmt.Errorf("debugger state: %w", err)
+	}
+
+	d := &Debugger{dbg: dbg, state: state, output: &Output{}, input: &Input{}, core: true}
+	d.selectCurrent()
+	if d.state.CurrentThread != nil {
+		d.SelectGoroutine(d.state.CurrentThread.GoroutineID)
+	}
+
+	return d, nil
+}
+
+func (d *Debugger) Step() error {
+	return d.command(api.Next)
+}
+
+func (d *Debugger) StepIn() error {
+	return d.command(api.Step)
+}
+
+func (d *Debugger) StepOut() error {
+	return d.command(api.StepOut)
+}
+
+// StepInstruction executes a single machine instruction, stepping into
+// calls.
+func (d *Debugger) StepInstruction() error {
+	return d.command(api.StepInstruction)
+}
+
+// NextInstruction executes a single machine instruction, stepping over
+// calls.
+func (d *Debugger) NextInstruction() error {
+	return d.command(api.NextInstruction)
 }
 
+// Continue resumes the target until it stops at a breakpoint. Logpoints
+// record their message and resume the target right away.
 func (d *Debugger) Continue() error {
-	state, err := d.dbg.Command(&api.DebuggerCommand{Name: api.Continue}, nil, nil)
+	for {
+		if err := d.command(api.Continue); err != nil {
+			return err
+		}
+		if !onlyLogpoints(d.state, d.formats) {
+			return nil
+		}
+	}
+}
+
+func (d *Debugger) command(name string) error {
+	d.returnValues, d.crash = nil, nil
+	state, err := d.dbg.Command(&api.DebuggerCommand{
+		Name:                 name,
+		ReturnInfoLoadConfig: api.LoadConfigFromProc(&loadConfig),
+	}, nil, nil)
 	if err != nil {
 		return err
 	}
 	d.state = state
+	d.selectCurrent()
+	d.returnValues = returnValues(state)
+	d.crash = crashAt(state, func(expr string) (*api.Variable, error) {
+		return d.eval(d.goroutineID, 0, expr)
+	})
+	if d.crash != nil {
+		// Show the user code that crashed rather than the runtime.
+		frames, _ := d.stacktrace(d.goroutineID, maxFrames)
+		d.frame = userFrame(frames)
+	}
+	recordLogpoints(state, d.formats, &d.logs)
+	d.watchEvents = watchEvents(state, d.watches, func(expr string) (*api.Variable, error) {
+		return d.eval(-1, 0, expr)
+	})
 	return nil
 }
 
+// Halt stops the target while an execution command is in progress. It
+// is safe to call from another goroutine.
+func (d *Debugger) Halt() error {
+	_, err := d.dbg.Command(&api.DebuggerCommand{Name: api.Halt}, nil, nil)
+	return err
+}
+
 func (d *Debugger) Variables() ([]api.Variable, error) {
 	if d.state.CurrentThread == nil {
 		return []api.Variable{}, nil
 	}
 
-	cfg := proc.LoadConfig{
-		FollowPointers:     true,
-		MaxVariableRecurse: 1,
-		MaxStringLen:       100,
-		MaxArrayValues:     64,
-		MaxStructFields:    -1,
-	}
-
-	args, err := d.dbg.FunctionArguments(d.state.CurrentThread.GoroutineID, 0, 0, cfg)
+	args, err := d.dbg.FunctionArguments(d.goroutineID, d.frame, 0, loadConfig)
 	if err != nil {
 		return nil, err
 	}
-	locals, err := d.dbg.LocalVariables(d.state.CurrentThread.GoroutineID, 0, 0, cfg)
+	locals, err := d.dbg.LocalVariables(d.goroutineID, d.frame, 0, loadConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -195,36 +406,471 @@ func (d *Debugger) Variables() ([]api.Variable, error) {
 	return api.ConvertVars(append(args, locals...)), nil
 }
 
-func (d *Debugger) CreateFileBreakpoint(file string, line int) error {
-	_, err := d.dbg.CreateBreakpoint(&api.Breakpoint{File: file, Line: line}, "", nil, false)
+// Eval evaluates expr in the selected goroutine and frame.
+func (d *Debugger) Eval(expr string) (*api.Variable, error) {
+	return d.eval(d.goroutineID, d.frame, expr)
+}
+
+func (d *Debugger) eval(goroutineID int64, frame int, expr string) (*api.Variable, error) {
+	v, err := d.dbg.EvalVariableInScope(goroutineID, frame, 0, expr, loadConfig)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertVar(v), nil
+}
+
+// Call injects a call like "s.String()" into the selected goroutine and
+// returns the values returned by it. If the call stops at a breakpoint
+// before returning, the new location is selected and no values are
+// returned; continuing finishes the call.
+func (d *Debugger) Call(expr string) ([]api.Variable, error) {
+	d.returnValues = nil
+	state, err := d.dbg.Command(&api.DebuggerCommand{
+		Name:                 api.Call,
+		Expr:                 expr,
+		GoroutineID:          d.goroutineID,
+		ReturnInfoLoadConfig: api.LoadConfigFromProc(&loadConfig),
+	}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", expr, err)
+	}
+	return callReturn(state, func() {
+		d.state = state
+		d.selectCurrent()
+	})
+}
+
+// ReturnValues returns the values returned by the function the last
+// step out returned from. They are cleared by the next command.
+func (d *Debugger) ReturnValues() []api.Variable {
+	return d.returnValues
+}
+
+// Crash returns the unrecovered panic or fatal error the target stopped
+// at, or nil if it stopped for another reason.
+func (d *Debugger) Crash() *Crash {
+	return d.crash
+}
+
+// SetVariable assigns value, a Go expression, to the variable denoted by
+// expr in the selected goroutine and frame.
+func (d *Debugger) SetVariable(expr, value string) error {
+	return d.dbg.SetVariableInScope(d.goroutineID, d.frame, 0, expr, value)
+}
+
+func (d *Debugger) CreateFileBreakpoint(file string, line int) (*api.Breakpoint, error) {
+	bp, err := d.dbg.CreateBreakpoint(&api.Breakpoint{File: file, Line: line}, "", nil, false)
+	if err != nil {
+		return nil, err
+	}
+	d.sources.add(bp.ID, file)
+	return bp, nil
+}
+
+// RunTo continues the target to file:line through a temporary breakpoint,
+// which is cleared once the target stops, at the line or elsewhere. A
+// breakpoint already set on the line is amended to stop instead.
+func (d *Debugger) RunTo(file string, line int) error {
+	bp := breakpointAt(d.Breakpoints(), file, line)
+	switch {
+	case bp == nil:
+		tmp, err := d.dbg.CreateBreakpoint(&api.Breakpoint{File: file, Line: line}, "", nil, false)
+		if err != nil {
+			return err
+		}
+		err = d.Continue()
+		d.dbg.ClearBreakpoint(&api.Breakpoint{ID: tmp.ID})
+		return err
+	case stopsAlways(bp):
+		return d.Continue()
+	}
+
+	// The breakpoint on the line stops unconditionally until the run
+	// ends, even if it is a logpoint.
+	if err := d.dbg.AmendBreakpoint(unconditional(bp)); err != nil {
+		return err
+	}
+	f, ok := d.formats[bp.ID]
+	delete(d.formats, bp.ID)
+	err := d.Continue()
+	if ok {
+		d.formats[bp.ID] = f
+	}
+	if amendErr := d.dbg.AmendBreakpoint(bp); err == nil {
+		err = amendErr
+	}
 	return err
 }
 
-func (d *Debugger) CreateFunctionBreakpoint(name string) error {
-	_, err := d.dbg.CreateBreakpoint(&api.Breakpoint{FunctionName: name}, "", nil, false)
+func (d *Debugger) CreateFunctionBreakpoint(name string) (*api.Breakpoint, error) {
+	bp, err := d.dbg.CreateBreakpoint(&api.Breakpoint{FunctionName: name}, "", nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if d.funcBreakpoints == nil {
+		d.funcBreakpoints = map[int]string{}
+	}
+	d.funcBreakpoints[bp.ID] = name
+	return bp, nil
+}
+
+// BreakpointFunction returns the function a breakpoint was created on.
+// Delve reports the file and line of its entry instead.
+func (d *Debugger) BreakpointFunction(id int) (string, bool) {
+	name, ok := d.funcBreakpoints[id]
+	return name, ok
+}
+
+// Functions returns the names of all functions in the binary.
+func (d *Debugger) Functions() ([]string, error) {
+	return d.dbg.Functions("", 0)
+}
+
+func (d *Debugger) createBreakpoint(bp *api.Breakpoint) error {
+	_, err := d.dbg.CreateBreakpoint(bp, "", nil, false)
 	return err
 }
 
+// SetBreakpointCondition sets the condition expression and the hit count
+// condition (e.g. "== 3" or "> 10") of a breakpoint. Empty strings remove
+// the respective condition.
+func (d *Debugger) SetBreakpointCondition(id int, cond, hitCond string) error {
+	bp := d.dbg.FindBreakpoint(id)
+	if bp == nil {
+		return fmt.Errorf("no breakpoint with id %d", id)
+	}
+	bp.Cond, bp.HitCond = cond, hitCond
+	return d.dbg.AmendBreakpoint(bp)
+}
+
+// SetBreakpointEnabled enables or disables a breakpoint. Disabled
+// breakpoints are kept but do not stop the target.
+func (d *Debugger) SetBreakpointEnabled(id int, enabled bool) error {
+	bp := d.dbg.FindBreakpoint(id)
+	if bp == nil {
+		return fmt.Errorf("no breakpoint with id %d", id)
+	}
+	bp.Disabled = !enabled
+	return d.dbg.AmendBreakpoint(bp)
+}
+
+// CreateLogpoint creates a breakpoint at file:line that does not stop the
+// target but adds format to Logs, with the expressions in braces replaced
+// by their values.
+func (d *Debugger) CreateLogpoint(file string, line int, format string) (*api.Breakpoint, error) {
+	f, err := parseLogFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	bp, err := d.dbg.CreateBreakpoint(&api.Breakpoint{File: file, Line: line, Tracepoint: true, Variables: f.exprs}, "", nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if d.formats == nil {
+		d.formats = map[int]*logFormat{}
+	}
+	d.formats[bp.ID] = f
+	d.sources.add(bp.ID, file)
+	return bp, nil
+}
+
+// LogpointFormat returns the message format of a logpoint.
+func (d *Debugger) LogpointFormat(id int) (string, bool) {
+	f, ok := d.formats[id]
+	if !ok {
+		return "", false
+	}
+	return f.source, true
+}
+
+// CreateWatchpoint stops the target when the memory of expr, evaluated in
+// the selected goroutine and frame, is accessed as given by wtype.
+func (d *Debugger) CreateWatchpoint(expr string, wtype api.WatchType) error {
+	v, err := d.Eval(expr)
+	if err != nil {
+		return err
+	}
+	bp, err := d.dbg.CreateWatchpoint(d.goroutineID, d.frame, 0, expr, wtype)
+	if err != nil {
+		return err
+	}
+	if d.watches == nil {
+		d.watches = map[int]*watch{}
+	}
+	d.watches[bp.ID] = newWatch(expr, v)
+	return nil
+}
+
+// WatchEvents returns the watchpoints that stopped the target during the
+// last command.
+func (d *Debugger) WatchEvents() []WatchEvent {
+	return d.watchEvents
+}
+
+func (d *Debugger) Logs() *Logs {
+	return &d.logs
+}
+
 func (d *Debugger) ClearBreakpoint(id int) error {
 	_, err := d.dbg.ClearBreakpoint(&api.Breakpoint{ID: id})
+	delete(d.formats, id)
+	delete(d.funcBreakpoints, id)
+	delete(d.watches, id)
 	return err
 }
 
 func (d *Debugger) Breakpoints() []*api.Breakpoint {
-	return d.dbg.Breakpoints(true)
+	return d.dbg.Breakpoints(false)
 }
 
 func (d *Debugger) Exited() bool {
 	return d.state.Exited
 }
 
+// ExitStatus returns the exit status of the target once it exited.
+func (d *Debugger) ExitStatus() int {
+	return d.state.ExitStatus
+}
+
+// Restart relaunches the target, rebuilding it first if the debugger
+// built it, and runs it to the initial breakpoints again. Breakpoints are
+// kept, except for watchpoints, which belong to the old process. After a
+// rebuild, breakpoints in edited files move with their lines. The
+// breakpoints that could not be set again are returned.
+func (d *Debugger) Restart() ([]api.DiscardedBreakpoint, error) {
+	var rels []relocation
+	if d.rebuild != nil {
+		// A failed build leaves the current process alone.
+		if err := d.rebuild(); err != nil {
+			return nil, fmt.Errorf("rebuild: %w", err)
+		}
+		rels = d.sources.relocate(d.Breakpoints())
+	}
+	for id := range d.watches {
+		d.ClearBreakpoint(id)
+	}
+
+	discarded, err := d.dbg.Restart(false, "", false, nil, [3]string{}, false)
+	if err != nil {
+		return nil, fmt.Errorf("restart: %w", err)
+	}
+
+	// Delve sets breakpoints again on their old lines, or discards them
+	// if the lines have no code anymore. The moved ones are cleared and
+	// created on their new lines below.
+	moved := map[int]bool{}
+	for _, r := range rels {
+		moved[r.bp.ID] = true
+		d.dbg.ClearBreakpoint(&api.Breakpoint{ID: r.bp.ID})
+	}
+	discarded = slices.DeleteFunc(discarded, func(bp api.DiscardedBreakpoint) bool {
+		return moved[bp.Breakpoint.ID]
+	})
+	for _, bp := range discarded {
+		delete(d.formats, bp.Breakpoint.ID)
+		delete(d.funcBreakpoints, bp.Breakpoint.ID)
+	}
+
+	// The sources the target was just built from are the reference for
+	// the next rebuild.
+	if d.rebuild != nil {
+		lines := d.sources.lines
+		d.sources = sources{}
+		for _, bp := range d.Breakpoints() {
+			if lines[bp.ID] {
+				d.sources.add(bp.ID, bp.File)
+			}
+		}
+	}
+	for _, r := range rels {
+		f := d.formats[r.bp.ID]
+		delete(d.formats, r.bp.ID)
+		if r.line == 0 {
+			discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: r.bp, Reason: "line changed in source"})
+			continue
+		}
+		bp, err := d.dbg.CreateBreakpoint(&api.Breakpoint{
+			File:       r.bp.File,
+			Line:       r.line,
+			Cond:       r.bp.Cond,
+			HitCond:    r.bp.HitCond,
+			Tracepoint: r.bp.Tracepoint,
+			Variables:  r.bp.Variables,
+		}, "", nil, false)
+		if err != nil {
+			discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: r.bp, Reason: err.Error()})
+			continue
+		}
+		// Delve creates breakpoints enabled.
+		if r.bp.Disabled {
+			bp.Disabled = true
+			if err := d.dbg.AmendBreakpoint(bp); err != nil {
+				d.dbg.ClearBreakpoint(bp)
+				discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: r.bp, Reason: err.Error()})
+				continue
+			}
+		}
+		if f != nil {
+			d.formats[bp.ID] = f
+		}
+		d.sources.add(bp.ID, bp.File)
+	}
+
+	if err := d.input.reopen(); err != nil {
+		return discarded, err
+	}
+
+	d.state = &api.DebuggerState{}
+	d.selectCurrent()
+	return discarded, d.Continue()
+}
+
 func (d *Debugger) Location() (string, int) {
-	if d.state.CurrentThread == nil || d.state.CurrentThread.File == "<autogenerated>" {
-		return "", 0
+	return frameLocation(d.state, d.goroutineID, d.frame, d.stacktrace)
+}
+
+// Disassemble returns the instructions of the function containing the PC
+// of the selected frame. AtPC marks the instruction at that PC.
+func (d *Debugger) Disassemble() ([]api.AsmInstruction, error) {
+	pc, err := framePC(d.state, d.goroutineID, d.frame, d.stacktrace)
+	if err != nil {
+		return nil, fmt.Errorf("disassemble: %w", err)
+	}
+	insts, err := d.dbg.Disassemble(d.goroutineID, pc, 0)
+	if err != nil {
+		return nil, fmt.Errorf("disassemble: %w", err)
+	}
+	out := make([]api.AsmInstruction, len(insts))
+	for i := range insts {
+		out[i] = api.ConvertAsmInstruction(insts[i], d.dbg.AsmInstructionText(&insts[i], proc.GoFlavour))
+	}
+	markPC(out, pc)
+	return out, nil
+}
+
+// Registers returns the CPU registers of the selected goroutine and
+// frame. Floating point and vector registers are only included if
+// floatingPoint is set.
+func (d *Debugger) Registers(floatingPoint bool) ([]api.Register, error) {
+	regs, err := d.dbg.ScopeRegisters(d.goroutineID, d.frame, 0)
+	if err != nil {
+		return nil, fmt.Errorf("registers: %w", err)
+	}
+	return api.ConvertRegisters(regs, d.dbg.DwarfRegisterToString, floatingPoint), nil
+}
+
+// Output returns the captured stdout and stderr of the target.
+func (d *Debugger) Output() *Output {
+	return d.output
+}
+
+// Input returns the stdin of the target.
+func (d *Debugger) Input() *Input {
+	return d.input
+}
+
+// ReadOnly reports whether the target can only be inspected, as is the
+// case for core files.
+func (d *Debugger) ReadOnly() bool {
+	return d.core
+}
+
+func (d *Debugger) Goroutines() ([]*api.Goroutine, error) {
+	gs, _, err := d.dbg.Goroutines(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertGoroutines(d.dbg.Target(), gs), nil
+}
+
+func (d *Debugger) GoroutineID() int64 {
+	return d.goroutineID
+}
+
+// SelectGoroutine makes the goroutine with the given id the one whose
+// location and variables are shown. The topmost frame outside of the
+// runtime is selected. Stepping continues with the selected goroutine.
+func (d *Debugger) SelectGoroutine(id int64) error {
+	frames, err := d.stacktrace(id, maxFrames)
+	if err != nil {
+		return err
+	}
+	state, err := d.dbg.Command(&api.DebuggerCommand{Name: api.SwitchGoroutine, GoroutineID: id}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("switch goroutine: %w", err)
+	}
+	d.state = state
+	d.goroutineID = id
+	d.frame = userFrame(frames)
+	return nil
+}
+
+// Stacktrace returns the frames of the selected goroutine.
+func (d *Debugger) Stacktrace() ([]api.Stackframe, error) {
+	return d.stacktrace(d.goroutineID, maxFrames)
+}
+
+func (d *Debugger) Frame() int {
+	return d.frame
+}
+
+// SelectFrame makes the frame with the given index in Stacktrace the one
+// whose location and variables are shown. Stepping is not affected and
+// always continues from the topmost frame.
+func (d *Debugger) SelectFrame(frame int) error {
+	frames, err := d.Stacktrace()
+	if err != nil {
+		return err
+	}
+	if frame < 0 || frame >= len(frames) {
+		return fmt.Errorf("no frame %d", frame)
+	}
+	d.frame = frame
+	return nil
+}
+
+func (d *Debugger) selectCurrent() {
+	d.goroutineID, d.frame = currentGoroutine(d.state), 0
+}
+
+func (d *Debugger) stacktrace(goroutineID int64, depth int) ([]api.Stackframe, error) {
+	frames, err := d.dbg.Stacktrace(goroutineID, depth, 0)
+	if err != nil {
+		return nil, err
+	}
+	return d.dbg.ConvertStacktrace(frames, nil)
+}
+
+// SourceDir returns the directory containing main.main according to the
+// debug info of the target binary.
+func (d *Debugger) SourceDir() string {
+	locs, _, err := d.dbg.FindLocation(-1, 0, 0, "main.main", false, nil)
+	if err != nil || len(locs) == 0 || locs[0].File == "" {
+		return "."
+	}
+	return filepath.Dir(locs[0].File)
+}
+
+// Attached reports whether the debugger is attached to a process it did
+// not start itself.
+func (d *Debugger) Attached() bool {
+	return d.attached
+}
+
+// Detach detaches from the target and optionally kills it. Subsequent
+// calls are no-ops.
+func (d *Debugger) Detach(kill bool) error {
+	if d.detached {
+		return nil
 	}
-	return d.state.CurrentThread.File, d.state.CurrentThread.Line
+	d.detached = true
+	defer d.output.Close()
+	defer d.input.Close()
+	return d.dbg.Detach(kill)
 }
 
+// Close detaches from the target. Processes started by the debugger are
+// killed, attached processes are left running.
 func (d *Debugger) Close() error {
-	return d.dbg.Detach(true)
+	return d.Detach(!d.attached)
 }
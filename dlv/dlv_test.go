@@ -0,0 +1,164 @@
+package dlv_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/philippta/godbg/dlv"
+)
+
+func TestCore(t *testing.T) {
+	binpath := buildCrash(t)
+	dir := filepath.Dir(binpath)
+
+	limit := &syscall.Rlimit{Cur: ^uint64(0), Max: ^uint64(0)}
+	if err := syscall.Setrlimit(syscall.RLIMIT_CORE, limit); err != nil {
+		t.Skipf("raise core limit: %v", err)
+	}
+
+	cmd := exec.Command(binpath)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOTRACEBACK=crash")
+	cmd.Run()
+
+	cores, _ := filepath.Glob(filepath.Join(dir, "core*"))
+	if len(cores) == 0 {
+		t.Skip("no core file written, check /proc/sys/kernel/core_pattern")
+	}
+
+	dbg, err := dlv.Core(binpath, cores[0])
+	if err != nil {
+		if strings.Contains(err.Error(), "too old") {
+			t.Skipf("unsupported go version: %v", err)
+		}
+		t.Fatalf("open core: %v", err)
+	}
+	defer dbg.Close()
+
+	if !dbg.ReadOnly() {
+		t.Errorf("core debugger is not read-only")
+	}
+	if err := dbg.Continue(); err == nil {
+		t.Errorf("continue on core file succeeded")
+	}
+
+	file, line := dbg.Location()
+	if filepath.Base(file) != "main.go" || line != 18 {
+		t.Errorf("location = %s:%d, want main.go:18", file, line)
+	}
+
+	vars, err := dbg.Variables()
+	if err != nil {
+		t.Fatalf("variables: %v", err)
+	}
+	var names []string
+	for _, v := range vars {
+		names = append(names, v.Name)
+	}
+	if got := strings.Join(names, ","); got != "items,total,counts" {
+		t.Errorf("variables = %s, want items,total,counts", got)
+	}
+
+	gs, err := dbg.Goroutines()
+	if err != nil {
+		t.Fatalf("goroutines: %v", err)
+	}
+	for _, g := range gs {
+		if err := dbg.SelectGoroutine(g.ID); err != nil {
+			t.Errorf("select goroutine %d: %v", g.ID, err)
+		}
+	}
+}
+
+func TestRestartRelocate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example\n\ngo 1.23\n")
+	write("main.go", `package main
+
+import "fmt"
+
+func main() {
+	a := 1
+	fmt.Println(a)
+	b := 2
+	fmt.Println(b)
+}
+`)
+
+	dbg, err := dlv.Build(".", nil, dlv.Options{BuildDir: dir, SkipGoVersionCheck: true})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	defer dbg.Close()
+
+	file := filepath.Join(dir, "main.go")
+	for _, line := range []int{6, 7, 8} {
+		if _, err := dbg.CreateFileBreakpoint(file, line); err != nil {
+			t.Fatalf("create breakpoint: %v", err)
+		}
+	}
+	// The breakpoint on line 6 is disabled and must stay so on its new line.
+	for _, bp := range dbg.Breakpoints() {
+		if bp.File == file && bp.Line == 6 {
+			if err := dbg.SetBreakpointEnabled(bp.ID, false); err != nil {
+				t.Fatalf("disable breakpoint: %v", err)
+			}
+		}
+	}
+
+	// Two lines are inserted before the breakpoints and the line of the
+	// second one is changed.
+	write("main.go", `package main
+
+import "fmt"
+
+func main() {
+	x := 0
+	fmt.Println(x)
+	a := 1
+	fmt.Println(a)
+	b := 3
+	fmt.Println(b)
+}
+`)
+	discarded, err := dbg.Restart()
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if len(discarded) != 1 || discarded[0].Breakpoint.Line != 8 || discarded[0].Reason != "line changed in source" {
+		t.Errorf("discarded = %+v, want line 8 changed in source", discarded)
+	}
+
+	var lines []int
+	for _, bp := range dbg.Breakpoints() {
+		if bp.File != file {
+			continue
+		}
+		lines = append(lines, bp.Line)
+		if disabled := bp.Line == 8; bp.Disabled != disabled {
+			t.Errorf("breakpoint on line %d: disabled = %v, want %v", bp.Line, bp.Disabled, disabled)
+		}
+	}
+	// Line 5 has the breakpoint on main.main.
+	slices.Sort(lines)
+	if !slices.Equal(lines, []int{5, 8, 9}) {
+		t.Errorf("breakpoint lines = %v, want [5 8 9]", lines)
+	}
+
+	if err := dbg.Continue(); err != nil {
+		t.Fatalf("continue: %v", err)
+	}
+	if _, line := dbg.Location(); line != 9 {
+		t.Errorf("line after restart = %d, want 9", line)
+	}
+}
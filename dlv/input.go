@@ -0,0 +1,98 @@
+package dlv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+var errNoInput = errors.New("program input is not available")
+
+// Input feeds the stdin of the target through a named pipe. The zero
+// value is an Input that is not connected to the target.
+type Input struct {
+	dir string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newInput() (*Input, error) {
+	dir, err := os.MkdirTemp("", "godbg")
+	if err != nil {
+		return nil, fmt.Errorf("create input dir: %w", err)
+	}
+
+	i := &Input{dir: dir}
+	if err := syscall.Mkfifo(i.Path(), 0600); err != nil {
+		i.Close()
+		return nil, fmt.Errorf("create pipe %s: %w", i.Path(), err)
+	}
+	// Opening read-write never blocks waiting for the target to open its
+	// end of the pipe.
+	i.file, err = os.OpenFile(i.Path(), os.O_RDWR, 0)
+	if err != nil {
+		i.Close()
+		return nil, fmt.Errorf("open pipe %s: %w", i.Path(), err)
+	}
+	return i, nil
+}
+
+func (i *Input) Path() string {
+	return filepath.Join(i.dir, "stdin")
+}
+
+// Available reports whether input can be sent to the target.
+func (i *Input) Available() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.file != nil
+}
+
+func (i *Input) Write(p []byte) (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.file == nil {
+		return 0, errNoInput
+	}
+	return i.file.Write(p)
+}
+
+// reopen opens the pipe again after CloseWrite, so a restarted target
+// can be sent input.
+func (i *Input) reopen() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.dir == "" || i.file != nil {
+		return nil
+	}
+	f, err := os.OpenFile(i.Path(), os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("open pipe %s: %w", i.Path(), err)
+	}
+	i.file = f
+	return nil
+}
+
+// CloseWrite closes the pipe so the target reads EOF.
+func (i *Input) CloseWrite() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.file == nil {
+		return errNoInput
+	}
+	err := i.file.Close()
+	i.file = nil
+	return err
+}
+
+func (i *Input) Close() error {
+	i.CloseWrite()
+	if i.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(i.dir)
+}
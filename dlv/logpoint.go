@@ -0,0 +1,162 @@
+package dlv
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+const maxLogEntries = 10000
+
+// LogEntry is a message printed by a logpoint.
+type LogEntry struct {
+	Time        time.Time
+	GoroutineID int64
+	File        string
+	Line        int
+	Message     string
+}
+
+// Logs keeps the most recent messages printed by logpoints.
+type Logs struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+}
+
+func (l *Logs) add(e LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) < maxLogEntries {
+		l.entries = append(l.entries, e)
+		return
+	}
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % maxLogEntries
+}
+
+// Entries returns the messages from oldest to newest.
+func (l *Logs) Entries() []LogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]LogEntry, 0, len(l.entries))
+	entries = append(entries, l.entries[l.next:]...)
+	entries = append(entries, l.entries[:l.next]...)
+	return entries
+}
+
+func (l *Logs) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = l.entries[:0]
+	l.next = 0
+}
+
+// logFormat is a parsed logpoint message like "user={user.Name} n={len(items)}".
+// The literal text and the expressions alternate, starting with text.
+type logFormat struct {
+	source string
+	text   []string
+	exprs  []string
+}
+
+func parseLogFormat(format string) (*logFormat, error) {
+	f := &logFormat{source: format}
+	for {
+		open := strings.IndexByte(format, '{')
+		if open < 0 {
+			if strings.IndexByte(format, '}') >= 0 {
+				return nil, errors.New("unexpected } in log message")
+			}
+			f.text = append(f.text, format)
+			return f, nil
+		}
+		end := strings.IndexByte(format[open:], '}')
+		if end < 0 {
+			return nil, errors.New("missing } in log message")
+		}
+		expr := strings.TrimSpace(format[open+1 : open+end])
+		if expr == "" {
+			return nil, errors.New("empty expression in log message")
+		}
+		f.text = append(f.text, format[:open])
+		f.exprs = append(f.exprs, expr)
+		format = format[open+end+1:]
+	}
+}
+
+// render substitutes the evaluated expressions into the message. Vars are
+// matched to the expressions by name, as returned by Delve.
+func (f *logFormat) render(vars []api.Variable) string {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		if v.Unreadable != "" {
+			values[v.Name] = "<" + v.Unreadable + ">"
+			continue
+		}
+		values[v.Name] = v.SinglelineString()
+	}
+
+	var sb strings.Builder
+	for i, text := range f.text {
+		sb.WriteString(text)
+		if i < len(f.exprs) {
+			if value, ok := values[f.exprs[i]]; ok {
+				sb.WriteString(value)
+			} else {
+				sb.WriteString("<unavailable>")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// recordLogpoints adds the messages of the logpoints hit in state to logs.
+func recordLogpoints(state *api.DebuggerState, formats map[int]*logFormat, logs *Logs) {
+	for _, th := range state.Threads {
+		if th.Breakpoint == nil {
+			continue
+		}
+		f, ok := formats[th.Breakpoint.ID]
+		if !ok {
+			continue
+		}
+
+		var vars []api.Variable
+		if th.BreakpointInfo != nil {
+			vars = th.BreakpointInfo.Variables
+		}
+		logs.add(LogEntry{
+			Time:        time.Now(),
+			GoroutineID: th.GoroutineID,
+			File:        th.File,
+			Line:        th.Line,
+			Message:     f.render(vars),
+		})
+	}
+}
+
+// onlyLogpoints reports whether the target stopped only because of
+// logpoints, meaning it should be continued.
+func onlyLogpoints(state *api.DebuggerState, formats map[int]*logFormat) bool {
+	if state.Exited {
+		return false
+	}
+	hit := false
+	for _, th := range state.Threads {
+		if th.Breakpoint == nil {
+			continue
+		}
+		if _, ok := formats[th.Breakpoint.ID]; !ok {
+			return false
+		}
+		hit = true
+	}
+	return hit
+}
@@ -0,0 +1,19 @@
+package dlv
+
+import "testing"
+
+func TestParseLogFormat(t *testing.T) {
+	f, err := parseLogFormat("user={u.Name} n={ len(items) }!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.exprs) != 2 || f.exprs[0] != "u.Name" || f.exprs[1] != "len(items)" {
+		t.Errorf("exprs = %q", f.exprs)
+	}
+
+	for _, format := range []string{"{u.Name", "u.Name}", "{}"} {
+		if _, err := parseLogFormat(format); err == nil {
+			t.Errorf("parseLogFormat(%q) succeeded", format)
+		}
+	}
+}
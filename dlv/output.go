@@ -0,0 +1,163 @@
+package dlv
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+const maxOutputLines = 10000
+
+type Stream int
+
+const (
+	Stdout Stream = iota
+	Stderr
+	// Stdin marks input sent to the target, echoed like a terminal would.
+	Stdin
+)
+
+type OutputLine struct {
+	Stream Stream
+	Text   string
+}
+
+// Output captures the stdout and stderr of the target and keeps the most
+// recent lines in a ring buffer. The streams are connected through named
+// pipes, so the redirects are plain paths that survive a restart of the
+// target. The zero value is an empty Output that never receives lines.
+type Output struct {
+	dir   string
+	files []*os.File
+
+	mu      sync.Mutex
+	lines   []OutputLine
+	next    int
+	partial [3][]byte
+}
+
+func newOutput() (*Output, error) {
+	dir, err := os.MkdirTemp("", "godbg")
+	if err != nil {
+		return nil, fmt.Errorf("create output dir: %w", err)
+	}
+
+	o := &Output{dir: dir}
+	for _, stream := range []Stream{Stdout, Stderr} {
+		path := o.path(stream)
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			o.Close()
+			return nil, fmt.Errorf("create pipe %s: %w", path, err)
+		}
+		// Opening read-write never blocks waiting for the other end and
+		// keeps the pipe open across restarts of the target.
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			o.Close()
+			return nil, fmt.Errorf("open pipe %s: %w", path, err)
+		}
+		o.files = append(o.files, f)
+		go o.read(stream, f)
+	}
+	return o, nil
+}
+
+func (o *Output) path(stream Stream) string {
+	if stream == Stderr {
+		return filepath.Join(o.dir, "stderr")
+	}
+	return filepath.Join(o.dir, "stdout")
+}
+
+func (o *Output) Redirects() (stdout, stderr proc.OutputRedirect) {
+	return proc.OutputRedirect{Path: o.path(Stdout)}, proc.OutputRedirect{Path: o.path(Stderr)}
+}
+
+func (o *Output) read(stream Stream, f *os.File) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			o.Write(stream, buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Write appends p to the given stream, splitting it into lines.
+func (o *Output) Write(stream Stream, p []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if stream == Stdin && len(o.partial[Stdout]) > 0 {
+		// Keep a pending prompt of the target above the echoed input.
+		o.add(OutputLine{Stream: Stdout, Text: string(o.partial[Stdout])})
+		o.partial[Stdout] = o.partial[Stdout][:0]
+	}
+
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			o.partial[stream] = append(o.partial[stream], p...)
+			return
+		}
+		line := append(o.partial[stream], p[:i]...)
+		o.partial[stream] = o.partial[stream][:0]
+		o.add(OutputLine{Stream: stream, Text: string(line)})
+		p = p[i+1:]
+	}
+}
+
+func (o *Output) add(line OutputLine) {
+	if len(o.lines) < maxOutputLines {
+		o.lines = append(o.lines, line)
+		return
+	}
+	o.lines[o.next] = line
+	o.next = (o.next + 1) % maxOutputLines
+}
+
+// Lines returns the buffered lines from oldest to newest, followed by
+// any line that is not terminated yet.
+func (o *Output) Lines() []OutputLine {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	lines := make([]OutputLine, 0, len(o.lines)+2)
+	lines = append(lines, o.lines[o.next:]...)
+	lines = append(lines, o.lines[:o.next]...)
+	for stream, partial := range o.partial {
+		if len(partial) > 0 {
+			lines = append(lines, OutputLine{Stream: Stream(stream), Text: string(partial)})
+		}
+	}
+	return lines
+}
+
+func (o *Output) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.lines = o.lines[:0]
+	o.next = 0
+	for i := range o.partial {
+		o.partial[i] = o.partial[i][:0]
+	}
+}
+
+func (o *Output) Close() error {
+	for _, f := range o.files {
+		f.Close()
+	}
+	if o.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(o.dir)
+}
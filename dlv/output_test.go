@@ -0,0 +1,49 @@
+package dlv
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestOutputLines(t *testing.T) {
+	var o Output
+	o.Write(Stdout, []byte("hello\nwor"))
+	o.Write(Stderr, []byte("oops\n"))
+	o.Write(Stdout, []byte("ld\nprompt> "))
+
+	want := []OutputLine{
+		{Stdout, "hello"},
+		{Stderr, "oops"},
+		{Stdout, "world"},
+		{Stdout, "prompt> "},
+	}
+	got := o.Lines()
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	o.Clear()
+	if got := o.Lines(); len(got) != 0 {
+		t.Errorf("got %d lines after clear", len(got))
+	}
+}
+
+func TestOutputRing(t *testing.T) {
+	var o Output
+	for i := 0; i < maxOutputLines+10; i++ {
+		o.Write(Stdout, []byte(strconv.Itoa(i)+"\n"))
+	}
+
+	lines := o.Lines()
+	if len(lines) != maxOutputLines {
+		t.Fatalf("got %d lines, want %d", len(lines), maxOutputLines)
+	}
+	if lines[0].Text != "10" || lines[len(lines)-1].Text != strconv.Itoa(maxOutputLines+9) {
+		t.Errorf("got lines %s..%s", lines[0].Text, lines[len(lines)-1].Text)
+	}
+}
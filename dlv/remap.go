@@ -0,0 +1,139 @@
+package dlv
+
+import (
+	"os"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// maxDiffCells limits the size of the table mapLines builds for the
+// changed part of a file. Larger changes leave their lines unmapped.
+const maxDiffCells = 1 << 22
+
+// sources keeps the contents of the files breakpoints are set in as they
+// were built, so the breakpoints can follow their lines when the files
+// are edited and the target is rebuilt. The zero value is ready to use.
+type sources struct {
+	files map[string][]string
+	// lines are the IDs of the breakpoints set on a line, as opposed to
+	// a function or an address.
+	lines map[int]bool
+}
+
+// add records that breakpoint id was set on a line of file and reads the
+// file unless its contents are known already.
+func (s *sources) add(id int, file string) {
+	if s.files == nil {
+		s.files = map[string][]string{}
+		s.lines = map[int]bool{}
+	}
+	s.lines[id] = true
+	if _, ok := s.files[file]; ok {
+		return
+	}
+	if lines, err := readLines(file); err == nil {
+		s.files[file] = lines
+	}
+}
+
+func readLines(file string) ([]string, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(src), "\n"), nil
+}
+
+// relocation is a breakpoint whose file changed so its line moved. line
+// is the new line, or 0 if the line itself was changed or deleted.
+type relocation struct {
+	bp   *api.Breakpoint
+	line int
+}
+
+// relocate compares the known contents of the files of bps with the
+// current ones and returns the breakpoints that are not on the same line
+// anymore.
+func (s *sources) relocate(bps []*api.Breakpoint) []relocation {
+	var (
+		rels  []relocation
+		lines = map[string][]int{}
+	)
+	for _, bp := range bps {
+		old, ok := s.files[bp.File]
+		if !ok || !s.lines[bp.ID] {
+			continue
+		}
+		m, ok := lines[bp.File]
+		if !ok {
+			cur, err := readLines(bp.File)
+			if err != nil {
+				continue
+			}
+			m = mapLines(old, cur)
+			lines[bp.File] = m
+		}
+		if bp.Line < 1 || bp.Line > len(m) || m[bp.Line-1]+1 == bp.Line {
+			continue
+		}
+		rels = append(rels, relocation{bp: bp, line: m[bp.Line-1] + 1})
+	}
+	return rels
+}
+
+// mapLines maps the index of each line of old to its index in cur, or to
+// -1 if it was changed or deleted. Unchanged lines are the longest common
+// subsequence of both.
+func mapLines(old, cur []string) []int {
+	m := make([]int, len(old))
+	for i := range m {
+		m[i] = -1
+	}
+
+	// Edits are usually small, so only the part between the common prefix
+	// and suffix is diffed.
+	pre := 0
+	for pre < len(old) && pre < len(cur) && old[pre] == cur[pre] {
+		m[pre] = pre
+		pre++
+	}
+	suf := 0
+	for suf < len(old)-pre && suf < len(cur)-pre && old[len(old)-1-suf] == cur[len(cur)-1-suf] {
+		m[len(old)-1-suf] = len(cur) - 1 - suf
+		suf++
+	}
+	a, b := old[pre:len(old)-suf], cur[pre:len(cur)-suf]
+	if len(a)*len(b) > maxDiffCells {
+		return m
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			m[pre+i] = pre + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return m
+}
@@ -0,0 +1,30 @@
+package dlv
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMapLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, cur string
+		want     []int
+	}{
+		{"unchanged", "a b c", "a b c", []int{0, 1, 2}},
+		{"inserted", "a b c", "a x y b c", []int{0, 3, 4}},
+		{"deleted", "a b c d", "a d", []int{0, -1, -1, 1}},
+		{"changed", "a b c", "a x c", []int{0, -1, 2}},
+		{"moved down", "a b c d", "x a b y c d", []int{1, 2, 4, 5}},
+		{"empty", "", "a", []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapLines(strings.Fields(tt.old), strings.Fields(tt.cur))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("mapLines(%q, %q) = %v, want %v", tt.old, tt.cur, got, tt.want)
+			}
+		})
+	}
+}
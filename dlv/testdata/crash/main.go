@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+type item struct {
+	Name  string
+	Count int
+}
+
+func process(items []item) {
+	total := 0
+	for _, it := range items {
+		total += it.Count
+	}
+	fmt.Println(total)
+
+	var counts map[string]int
+	counts[items[0].Name] = total
+}
+
+func main() {
+	process([]item{{"a", 1}, {"b", double(1)}})
+}
+
+func double(n int) int {
+	return n * 2
+}
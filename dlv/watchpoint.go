@@ -0,0 +1,103 @@
+package dlv
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+)
+
+// WatchEvent describes a watchpoint that stopped the target, either
+// because the watched memory was accessed or because the watched variable
+// went out of scope. Delve clears watchpoints that went out of scope.
+type WatchEvent struct {
+	Expr       string
+	OldValue   string
+	NewValue   string
+	File       string
+	Line       int
+	Function   string
+	OutOfScope bool
+}
+
+func (e WatchEvent) String() string {
+	if e.OutOfScope {
+		return fmt.Sprintf("watchpoint %s went out of scope and was cleared", e.Expr)
+	}
+	loc := fmt.Sprintf("%s:%d", filepath.Base(e.File), e.Line)
+	if e.Function != "" {
+		loc += " (" + e.Function + ")"
+	}
+	if e.OldValue == e.NewValue {
+		return fmt.Sprintf("watchpoint %s = %s accessed at %s", e.Expr, e.NewValue, loc)
+	}
+	return fmt.Sprintf("watchpoint %s: %s -> %s at %s", e.Expr, e.OldValue, e.NewValue, loc)
+}
+
+// ParseWatchType parses "r", "w" or "rw" into a watchpoint type.
+func ParseWatchType(s string) (api.WatchType, error) {
+	switch strings.TrimSpace(s) {
+	case "r":
+		return api.WatchRead, nil
+	case "w", "":
+		return api.WatchWrite, nil
+	case "rw", "wr":
+		return api.WatchRead | api.WatchWrite, nil
+	}
+	return 0, fmt.Errorf("invalid watchpoint type %q, want r, w or rw", s)
+}
+
+// watch is the watched memory of a watchpoint and its last known value.
+// The value is re-read through the address, as the expression may not be
+// valid in the scope of the goroutine that accessed it.
+type watch struct {
+	expr  string
+	typ   string
+	addr  uint64
+	value string
+}
+
+func newWatch(expr string, v *api.Variable) *watch {
+	return &watch{expr: expr, typ: v.Type, addr: v.Addr, value: watchValue(v)}
+}
+
+func watchValue(v *api.Variable) string {
+	if v.Unreadable != "" {
+		return "<" + v.Unreadable + ">"
+	}
+	return v.SinglelineString()
+}
+
+// watchEvents returns the watchpoints that stopped the target in state and
+// updates their last known values.
+func watchEvents(state *api.DebuggerState, watches map[int]*watch, eval func(expr string) (*api.Variable, error)) []WatchEvent {
+	var events []WatchEvent
+	for _, th := range state.Threads {
+		if th.Breakpoint == nil {
+			continue
+		}
+		w, ok := watches[th.Breakpoint.ID]
+		if !ok {
+			continue
+		}
+
+		event := WatchEvent{Expr: w.expr, OldValue: w.value, NewValue: "<unavailable>", File: th.File, Line: th.Line}
+		if th.Function != nil {
+			event.Function = th.Function.Name()
+		}
+		if v, err := eval(fmt.Sprintf("*(*%s)(%#x)", w.typ, w.addr)); err == nil {
+			event.NewValue = watchValue(v)
+			w.value = event.NewValue
+		}
+		events = append(events, event)
+	}
+
+	for _, bp := range state.WatchOutOfScope {
+		if w, ok := watches[bp.ID]; ok {
+			events = append(events, WatchEvent{Expr: w.expr, OldValue: w.value, OutOfScope: true})
+			delete(watches, bp.ID)
+		}
+	}
+	return events
+}
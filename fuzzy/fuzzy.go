@@ -69,3 +69,39 @@ func Match(files []util.Chars, pattern string) []string {
 	return out
 
 }
+
+// Filter returns the indices of the items matching pattern, best matches
+// first. An empty pattern matches all items in their original order.
+func Filter(items []string, pattern string) []int {
+	if pattern == "" {
+		out := make([]int, len(items))
+		for i := range items {
+			out[i] = i
+		}
+		return out
+	}
+
+	type scored struct {
+		score int
+		index int
+	}
+
+	var found []scored
+	for i, item := range items {
+		chars := util.ToChars([]byte(item))
+		res, _ := algo.FuzzyMatchV2(false, false, false, &chars, []rune(pattern), true, nil)
+		if res.Score > 0 {
+			found = append(found, scored{res.Score, i})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].score > found[j].score
+	})
+
+	out := make([]int, len(found))
+	for i, f := range found {
+		out[i] = f.index
+	}
+	return out
+}
@@ -12,3 +12,15 @@ func TestMatch(t *testing.T) {
 		fmt.Println(f)
 	}
 }
+
+func TestFilter(t *testing.T) {
+	items := []string{"main.main", "runtime.gopark", "main.worker"}
+
+	if got := Filter(items, ""); len(got) != 3 || got[0] != 0 || got[2] != 2 {
+		t.Errorf("Filter(\"\") = %v, want all items in order", got)
+	}
+	got := Filter(items, "work")
+	if len(got) != 1 || got[0] != 2 {
+		t.Errorf("Filter(\"work\") = %v, want [2]", got)
+	}
+}
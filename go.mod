@@ -11,6 +11,7 @@ require (
 
 require (
 	github.com/cilium/ebpf v0.11.0 // indirect
+	github.com/google/go-dap v0.12.0 // indirect
 	github.com/hashicorp/golang-lru v1.0.2 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
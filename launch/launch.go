@@ -0,0 +1,174 @@
+// Package launch reads the named launch configurations of a project from
+// its .godbg.json file.
+package launch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FileName is the name of the launch file, which is looked up in the
+// working directory and its parents.
+const FileName = ".godbg.json"
+
+const (
+	ModeDebug = "debug"
+	ModeTest  = "test"
+	ModeExec  = "exec"
+)
+
+type Config struct {
+	Name string `json:"name"`
+	// Mode is debug, test or exec. It defaults to debug.
+	Mode string `json:"mode,omitempty"`
+	// Package is the package to build, or the binary to run in exec mode.
+	Package string            `json:"package,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Cwd     string            `json:"cwd,omitempty"`
+	// BuildFlags are passed to go build or go test, like -tags or -race.
+	BuildFlags []string `json:"buildFlags,omitempty"`
+	// Test selects the tests to run in test mode.
+	Test        string       `json:"test,omitempty"`
+	Breakpoints []Breakpoint `json:"breakpoints,omitempty"`
+}
+
+// Breakpoint is set on a line, or on a function if File is empty. In the
+// launch file it is written as "file:line" or as a function name.
+type Breakpoint struct {
+	File     string
+	Line     int
+	Function string
+}
+
+func (b *Breakpoint) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if i := strings.LastIndexByte(s, ':'); i > 0 {
+		if line, err := strconv.Atoi(s[i+1:]); err == nil {
+			*b = Breakpoint{File: s[:i], Line: line}
+			return nil
+		}
+	}
+	if s == "" {
+		return errors.New("empty breakpoint")
+	}
+	*b = Breakpoint{Function: s}
+	return nil
+}
+
+func (b Breakpoint) String() string {
+	if b.File == "" {
+		return b.Function
+	}
+	return fmt.Sprintf("%s:%d", b.File, b.Line)
+}
+
+// Find returns the path of the launch file in dir or the closest of its
+// parents.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		path := filepath.Join(d, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if d == filepath.Dir(d) {
+			return "", fmt.Errorf("no %s in %s or its parents", FileName, dir)
+		}
+	}
+}
+
+// Load reads the configurations of the launch file at path. Relative
+// packages, working directories and breakpoint files are resolved against
+// the directory of the file.
+func Load(path string) ([]Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Configurations []Config `json:"configurations"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+
+	root := filepath.Dir(path)
+	seen := map[string]bool{}
+	for i := range file.Configurations {
+		c := &file.Configurations[i]
+		if c.Name == "" {
+			return nil, fmt.Errorf("%s: configuration %d has no name", path, i+1)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("%s: duplicate configuration %q", path, c.Name)
+		}
+		seen[c.Name] = true
+
+		switch c.Mode {
+		case "":
+			c.Mode = ModeDebug
+		case ModeDebug, ModeTest, ModeExec:
+		default:
+			return nil, fmt.Errorf("%s: configuration %q: unknown mode %q", path, c.Name, c.Mode)
+		}
+
+		c.Package = resolve(root, c.Package, c.Mode == ModeExec)
+		if c.Cwd != "" {
+			c.Cwd = resolve(root, c.Cwd, true)
+		}
+		for j, bp := range c.Breakpoints {
+			if bp.File != "" {
+				c.Breakpoints[j].File = resolve(root, bp.File, true)
+			}
+		}
+	}
+	return file.Configurations, nil
+}
+
+// resolve makes a path relative to root absolute. Package paths are only
+// resolved if they start with . or .., as others are import paths.
+func resolve(root, path string, isFile bool) string {
+	switch {
+	case path == "":
+		return root
+	case filepath.IsAbs(path):
+		return path
+	case isFile, path == ".", path == "..", strings.HasPrefix(path, "./"), strings.HasPrefix(path, "../"):
+		return filepath.Join(root, path)
+	}
+	return path
+}
+
+// Lookup returns the configuration called name.
+func Lookup(configs []Config, name string) (Config, error) {
+	for _, c := range configs {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return Config{}, fmt.Errorf("no configuration %q", name)
+}
+
+// Environ returns the environment variables of c as key=value pairs,
+// ordered by key.
+func (c Config) Environ() []string {
+	env := make([]string, 0, len(c.Env))
+	for k, v := range c.Env {
+		env = append(env, k+"="+v)
+	}
+	slices.Sort(env)
+	return env
+}
@@ -0,0 +1,103 @@
+package launch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, FileName)
+	data := `{
+	"configurations": [
+		{
+			"name": "server",
+			"package": "./cmd/server",
+			"args": ["-port", "8080"],
+			"env": {"LOG": "debug", "DB": "test"},
+			"cwd": "testdata",
+			"buildFlags": ["-tags", "dev"],
+			"breakpoints": ["server.go:42", "main.run"]
+		},
+		{
+			"name": "store tests",
+			"mode": "test",
+			"package": "example.com/app/store",
+			"test": "TestGet"
+		}
+	]
+}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := []Config{
+		{
+			Name:       "server",
+			Mode:       ModeDebug,
+			Package:    filepath.Join(root, "cmd", "server"),
+			Args:       []string{"-port", "8080"},
+			Env:        map[string]string{"LOG": "debug", "DB": "test"},
+			Cwd:        filepath.Join(root, "testdata"),
+			BuildFlags: []string{"-tags", "dev"},
+			Breakpoints: []Breakpoint{
+				{File: filepath.Join(root, "server.go"), Line: 42},
+				{Function: "main.run"},
+			},
+		},
+		{
+			Name:    "store tests",
+			Mode:    ModeTest,
+			Package: "example.com/app/store",
+			Test:    "TestGet",
+		},
+	}
+	if !reflect.DeepEqual(configs, want) {
+		t.Errorf("configs = %+v, want %+v", configs, want)
+	}
+	if env := configs[0].Environ(); !reflect.DeepEqual(env, []string{"DB=test", "LOG=debug"}) {
+		t.Errorf("environ = %v", env)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no name":   `{"configurations": [{"package": "."}]}`,
+		"duplicate": `{"configurations": [{"name": "a"}, {"name": "a"}]}`,
+		"mode":      `{"configurations": [{"name": "a", "mode": "attach"}]}`,
+	}
+	for name, data := range tests {
+		path := filepath.Join(t.TempDir(), FileName)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: load succeeded", name)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "cmd", "app")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Find(dir)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if want := filepath.Join(root, FileName); got != want {
+		t.Errorf("path = %s, want %s", got, want)
+	}
+}
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/philippta/godbg/debug"
 	"github.com/philippta/godbg/dlv"
+	"github.com/philippta/godbg/launch"
+	"github.com/philippta/godbg/session"
 	"github.com/philippta/godbg/ui"
 )
 
-const usage = "Usage: godbg <debug|test|exec> [path] [func regex]"
+const usage = `Usage: godbg <debug|test|exec|attach|core|connect|run> [--stdin file] [--no-session] [path|pid|addr|name] [func regex|corefile] [args]
+
+Flags must come before the path, pid, address or name. The arguments
+after it are passed to the program, including ones that look like flags.`
 
 func main() {
 	debug.Truncate()
@@ -23,52 +32,282 @@ func main() {
 
 	switch args[0] {
 	case "debug":
+		opts, args := parseFlags(args, true)
 		var path string
+		if len(args) > 0 {
+			path = args[0]
+		}
+		var progArgs []string
 		if len(args) > 1 {
-			path = args[1]
+			progArgs = args[1:]
 		}
 
-		dbg, err := dlv.Build(path, args[2:])
+		dir, _ := filepath.Abs(filepath.Dir(path))
+		uiOpts := opts.uiOptions(dir)
+		opts.Init = initBreakpoints(&uiOpts, nil)
+		dbg, err := dlv.Build(path, progArgs, opts.Options)
 		if err != nil {
 			panic(err)
 		}
 		defer dbg.Close()
 
-		path, _ = filepath.Abs(filepath.Dir(path))
-		ui.Run(dbg, path)
+		ui.Run(dbg, dir, uiOpts)
 	case "test":
+		opts, args := parseFlags(args, true)
 		var path string
-		if len(args) > 1 {
-			path = args[1]
+		if len(args) > 0 {
+			path = args[0]
 		}
 		var funcExpr string
+		if len(args) > 1 {
+			funcExpr = args[1]
+		}
+		var progArgs []string
 		if len(args) > 2 {
-			funcExpr = args[2]
+			progArgs = args[2:]
 		}
 
-		dbg, err := dlv.Test(path, funcExpr)
+		dir, _ := filepath.Abs(filepath.Dir(path))
+		uiOpts := opts.uiOptions(dir)
+		opts.Init = initBreakpoints(&uiOpts, nil)
+		dbg, err := dlv.Test(path, funcExpr, progArgs, opts.Options)
 		if err != nil {
 			panic(err)
 		}
 		defer dbg.Close()
 
-		path, _ = filepath.Abs(filepath.Dir(path))
-		ui.Run(dbg, path)
+		ui.Run(dbg, dir, uiOpts)
 	case "exec":
+		opts, args := parseFlags(args, true)
+		if len(args) < 1 {
+			fmt.Fprintln(os.Stderr, usage)
+			return
+		}
+		path := args[0]
+		dir, _ := filepath.Abs(filepath.Dir(path))
+		uiOpts := opts.uiOptions(dir)
+		opts.Init = initBreakpoints(&uiOpts, nil)
+		dbg, err := dlv.Exec(path, args[1:], opts.Options)
+		if err != nil {
+			panic(err)
+		}
+		defer dbg.Close()
+
+		ui.Run(dbg, dir, uiOpts)
+	case "attach":
+		opts, args := parseFlags(args, false)
+		if len(args) < 1 {
+			fmt.Fprintln(os.Stderr, usage)
+			return
+		}
+		pid, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, usage)
+			return
+		}
+		dbg, err := dlv.Attach(pid)
+		if err != nil {
+			panic(err)
+		}
+		defer dbg.Close()
+
+		run(dbg, dbg.SourceDir(), opts)
+	case "core":
+		opts, args := parseFlags(args, false)
 		if len(args) < 2 {
 			fmt.Fprintln(os.Stderr, usage)
 			return
 		}
-		path := args[1]
-		dbg, err := dlv.Exec(path)
+		dbg, err := dlv.Core(args[0], args[1])
+		if err != nil {
+			panic(err)
+		}
+		defer dbg.Close()
+
+		run(dbg, dbg.SourceDir(), opts)
+	case "connect":
+		opts, args := parseFlags(args, false)
+		if len(args) < 1 {
+			fmt.Fprintln(os.Stderr, usage)
+			return
+		}
+		dbg, err := dlv.Connect(args[0])
 		if err != nil {
 			panic(err)
 		}
 		defer dbg.Close()
 
-		path, _ = filepath.Abs(filepath.Dir(path))
-		ui.Run(dbg, path)
+		run(dbg, dbg.SourceDir(), opts)
+	case "run":
+		opts, args := parseFlags(args, true)
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if err := runConfig(name, opts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+	}
+}
+
+type options struct {
+	dlv.Options
+	noSession bool
+}
+
+// parseFlags parses the flags of a subcommand and returns the remaining
+// arguments. The flags configuring how a target is started are only
+// accepted by the subcommands that launch one. Parsing stops at the first
+// argument that is not a flag, so flags of the program are left to it.
+func parseFlags(args []string, launch bool) (options, []string) {
+	var opts options
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		fmt.Fprintln(os.Stderr)
+		fs.PrintDefaults()
+	}
+	if launch {
+		fs.StringVar(&opts.Stdin, "stdin", "", "read program input from `file`")
+	}
+	fs.BoolVar(&opts.noSession, "no-session", false, "do not restore or save breakpoints, watches and layout")
+	fs.Parse(args[1:])
+	return opts, fs.Args()
+}
+
+// uiOptions returns the options of the UI for a target in dir, with the session
+// of the module containing dir, unless sessions are disabled.
+func (opts options) uiOptions(dir string) ui.Options {
+	var o ui.Options
+	if !opts.noSession {
+		// Without a module there is no place for the session.
+		o.SessionPath, _ = session.Path(dir)
+	}
+	return o
+}
+
+// run shows the UI for a target that was not launched by godbg, which
+// gets the breakpoints of the session once it is shown.
+func run(dbg dlv.Backend, dir string, opts options) {
+	ui.Run(dbg, dir, opts.uiOptions(dir))
+}
+
+// initBreakpoints returns a dlv.Options.Init that sets bps and the
+// breakpoints of the session of o before the target runs, so the ones in
+// init functions are hit. Breakpoints that cannot be set are reported in
+// o.Err.
+func initBreakpoints(o *ui.Options, bps []launch.Breakpoint) func(*dlv.Debugger) {
+	return func(dbg *dlv.Debugger) {
+		var errs []string
+		if err := setBreakpoints(dbg, bps); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if o.SessionPath != "" {
+			if err := ui.RestoreBreakpoints(dbg, o.SessionPath); err != nil {
+				errs = append(errs, err.Error())
+			}
+			o.BreakpointsRestored = true
+		}
+		if len(errs) > 0 {
+			o.Err = errors.New(strings.Join(errs, "; "))
+		}
+	}
+}
+
+// runConfig launches the configuration called name from the launch file of
+// the working directory. Without a name, it asks which one to launch.
+func runConfig(name string, opts options) error {
+	path, err := launch.Find(".")
+	if err != nil {
+		return err
+	}
+	configs, err := launch.Load(path)
+	if err != nil {
+		return err
+	}
+	root := filepath.Dir(path)
+
+	var cfg launch.Config
+	if name != "" {
+		cfg, err = launch.Lookup(configs, name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		if len(configs) == 0 {
+			return fmt.Errorf("%s: no configurations", path)
+		}
+		i, ok := ui.Pick("Launch configurations", configLabels(configs, root))
+		if !ok {
+			return nil
+		}
+		cfg = configs[i]
+	}
+
+	opts.Env = cfg.Environ()
+	opts.Dir = cfg.Cwd
+	opts.BuildFlags = cfg.BuildFlags
+	uiOpts := opts.uiOptions(root)
+	opts.Init = initBreakpoints(&uiOpts, cfg.Breakpoints)
+
+	var dbg *dlv.Debugger
+	switch cfg.Mode {
+	case launch.ModeDebug:
+		dbg, err = dlv.Build(cfg.Package, cfg.Args, opts.Options)
+	case launch.ModeTest:
+		dbg, err = dlv.Test(cfg.Package, cfg.Test, cfg.Args, opts.Options)
+	case launch.ModeExec:
+		dbg, err = dlv.Exec(cfg.Package, cfg.Args, opts.Options)
+	}
+	if err != nil {
+		return fmt.Errorf("launch %s: %w", cfg.Name, err)
+	}
+	defer dbg.Close()
+
+	ui.Run(dbg, root, uiOpts)
+	return nil
+}
+
+// setBreakpoints sets the breakpoints of a launch configuration. The ones
+// that cannot be set are reported together, without stopping the launch.
+func setBreakpoints(dbg dlv.Backend, bps []launch.Breakpoint) error {
+	var failed []string
+	for _, bp := range bps {
+		var err error
+		if bp.File != "" {
+			_, err = dbg.CreateFileBreakpoint(bp.File, bp.Line)
+		} else {
+			_, err = dbg.CreateFunctionBreakpoint(bp.Function)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", bp, err))
+		}
 	}
+	if len(failed) > 0 {
+		return errors.New("breakpoints of the launch configuration not set: " + strings.Join(failed, "; "))
+	}
+	return nil
+}
 
-	fmt.Fprintln(os.Stderr, "Usage: godbg <debug|test> [path] [func regex]")
+// configLabels describes the configurations for the picker, with their
+// mode and package.
+func configLabels(configs []launch.Config, root string) []string {
+	width := 0
+	for _, c := range configs {
+		width = max(width, len(c.Name))
+	}
+	labels := make([]string, len(configs))
+	for i, c := range configs {
+		pkg := c.Package
+		if pkg == root {
+			pkg = "."
+		} else if rel, err := filepath.Rel(root, pkg); err == nil && filepath.IsLocal(rel) {
+			pkg = "./" + rel
+		}
+		labels[i] = fmt.Sprintf("%-*s  %-5s %s", width, c.Name, c.Mode, pkg)
+	}
+	return labels
 }
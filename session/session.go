@@ -0,0 +1,108 @@
+// Package session stores the breakpoints, watch expressions and layout of
+// a project between runs of godbg.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type Session struct {
+	Breakpoints []Breakpoint `json:"breakpoints,omitempty"`
+	Watches     []string     `json:"watches,omitempty"`
+	// Expanded are the paths of the expanded variables.
+	Expanded []string `json:"expanded,omitempty"`
+	Layout   Layout   `json:"layout"`
+}
+
+// Breakpoint is set on a line, or on a function if File is empty.
+type Breakpoint struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Function string `json:"function,omitempty"`
+	Cond     string `json:"cond,omitempty"`
+	HitCond  string `json:"hitCond,omitempty"`
+	// Log is the message format of a logpoint.
+	Log      string `json:"log,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// Layout holds which of the optional panes are open.
+type Layout struct {
+	Output      bool `json:"output,omitempty"`
+	Logs        bool `json:"logs,omitempty"`
+	Goroutines  bool `json:"goroutines,omitempty"`
+	Stack       bool `json:"stack,omitempty"`
+	Registers   bool `json:"registers,omitempty"`
+	Breakpoints bool `json:"breakpoints,omitempty"`
+	Disassembly bool `json:"disassembly,omitempty"`
+}
+
+// Path returns the path of the session file of the module containing
+// dir, which is .godbg/session.json in the module root.
+func Path(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return filepath.Join(d, ".godbg", "session.json"), nil
+		}
+		if d == filepath.Dir(d) {
+			return "", fmt.Errorf("no go.mod in %s or its parents", dir)
+		}
+	}
+}
+
+// Load reads the session file at path. A missing file is an empty
+// session.
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Session{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", path, err)
+	}
+	// Files are stored relative to the module root, so the session
+	// survives moving the project.
+	root := filepath.Dir(filepath.Dir(path))
+	for i, bp := range s.Breakpoints {
+		if bp.File != "" && !filepath.IsAbs(bp.File) {
+			s.Breakpoints[i].File = filepath.Join(root, bp.File)
+		}
+	}
+	return &s, nil
+}
+
+// Save writes the session file at path.
+func (s *Session) Save(path string) error {
+	root := filepath.Dir(filepath.Dir(path))
+	saved := *s
+	saved.Breakpoints = make([]Breakpoint, len(s.Breakpoints))
+	for i, bp := range s.Breakpoints {
+		if rel, err := filepath.Rel(root, bp.File); err == nil && filepath.IsLocal(rel) {
+			bp.File = rel
+		}
+		saved.Breakpoints[i] = bp
+	}
+
+	data, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		return fmt.Errorf("encode session: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
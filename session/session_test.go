@@ -0,0 +1,73 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkg := filepath.Join(root, "cmd", "app")
+	if err := os.MkdirAll(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Path(pkg)
+	if err != nil {
+		t.Fatalf("path: %v", err)
+	}
+	if want := filepath.Join(root, ".godbg", "session.json"); got != want {
+		t.Errorf("path = %s, want %s", got, want)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".godbg", "session.json")
+
+	s := &Session{
+		Breakpoints: []Breakpoint{
+			{File: filepath.Join(root, "main.go"), Line: 12, Cond: "i > 3"},
+			{Function: "main.run", Disabled: true},
+			{File: filepath.Join(root, "log.go"), Line: 5, Log: "x={x}"},
+		},
+		Watches:  []string{"len(items)"},
+		Expanded: []string{"items"},
+		Layout:   Layout{Stack: true, Breakpoints: true},
+	}
+	if err := s.Save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), root) {
+		t.Errorf("session contains absolute paths:\n%s", data)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("loaded session = %+v, want %+v", got, s)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "session.json"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(s.Breakpoints) != 0 || len(s.Watches) != 0 {
+		t.Errorf("session = %+v, want empty", s)
+	}
+}
@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/frame"
+)
+
+type Breakpoints struct {
+	Focused     bool
+	Size        Size
+	Breakpoints []*api.Breakpoint
+	LineCursor  int
+	LineStart   int
+}
+
+func (b *Breakpoints) Resize(w, h int) {
+	b.Size.Width, b.Size.Height = w, h
+	b.AlignCursor()
+}
+
+// Load replaces the breakpoints, ordered by ID. Internal breakpoints, like
+// the ones stopping at crashes, are left out.
+func (b *Breakpoints) Load(bps []*api.Breakpoint) {
+	b.Breakpoints = b.Breakpoints[:0]
+	for _, bp := range bps {
+		if bp.ID > 0 {
+			b.Breakpoints = append(b.Breakpoints, bp)
+		}
+	}
+	slices.SortFunc(b.Breakpoints, func(x, y *api.Breakpoint) int {
+		return x.ID - y.ID
+	})
+	b.AlignCursor()
+}
+
+// Selected returns the breakpoint under the cursor.
+func (b *Breakpoints) Selected() (*api.Breakpoint, bool) {
+	if b.LineCursor >= len(b.Breakpoints) {
+		return nil, false
+	}
+	return b.Breakpoints[b.LineCursor], true
+}
+
+func (b *Breakpoints) MoveUp() {
+	b.LineCursor = max(0, b.LineCursor-1)
+	b.AlignCursor()
+}
+
+func (b *Breakpoints) MoveDown() {
+	b.LineCursor = min(b.LineCursor+1, max(0, len(b.Breakpoints)-1))
+	b.AlignCursor()
+}
+
+func (b *Breakpoints) AlignCursor() {
+	height := b.Size.Height - 1
+	b.LineCursor = min(b.LineCursor, max(0, len(b.Breakpoints)-1))
+	if b.LineCursor < b.LineStart {
+		b.LineStart = b.LineCursor
+	}
+	if b.LineCursor > b.LineStart+height-1 {
+		b.LineStart = b.LineCursor - height + 1
+	}
+	b.LineStart = max(0, min(b.LineStart, len(b.Breakpoints)-height))
+}
+
+// breakpointLocation describes where a breakpoint is set.
+func breakpointLocation(bp *api.Breakpoint) string {
+	switch {
+	case bp.WatchExpr != "":
+		return "watch " + bp.WatchExpr
+	case bp.File == "" || bp.File == "<multiple locations>":
+		return bp.FunctionName
+	}
+	loc := fmt.Sprintf("%s:%d", filepath.Base(bp.File), bp.Line)
+	if bp.FunctionName != "" {
+		loc += " " + bp.FunctionName
+	}
+	return loc
+}
+
+// breakpointHits describes how often a breakpoint was hit, in total and by
+// goroutine.
+func breakpointHits(bp *api.Breakpoint) string {
+	if bp.TotalHitCount == 0 {
+		return ""
+	}
+	ids := make([]int64, 0, len(bp.HitCount))
+	for id := range bp.HitCount {
+		n, _ := strconv.ParseInt(id, 10, 64)
+		ids = append(ids, n)
+	}
+	slices.Sort(ids)
+
+	counts := make([]string, len(ids))
+	for i, id := range ids {
+		counts[i] = fmt.Sprintf("g%d: %d", id, bp.HitCount[strconv.FormatInt(id, 10)])
+	}
+	return fmt.Sprintf("hits %d (%s)", bp.TotalHitCount, strings.Join(counts, ", "))
+}
+
+func (b *Breakpoints) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	renderTitle(text, colors, offsetY, offsetX, b.Size.Width, " Breakpoints ", b.Focused)
+
+	idWidth, locWidth := 0, 0
+	for _, bp := range b.Breakpoints {
+		idWidth = max(idWidth, len(strconv.Itoa(bp.ID)))
+		locWidth = max(locWidth, len(breakpointLocation(bp)))
+	}
+
+	lineEnd := min(b.LineStart+b.Size.Height-1, len(b.Breakpoints))
+	for i := b.LineStart; i < lineEnd; i++ {
+		y := i - b.LineStart + offsetY + 1
+		x := offsetX
+		bp := b.Breakpoints[i]
+		end := offsetX + b.Size.Width
+
+		if i == b.LineCursor {
+			x = text.WriteString(y, x, "=> ")
+		} else {
+			x = text.WriteString(y, x, "   ")
+		}
+		colors.SetColor(y, offsetX, 3, cursorColor(b.Focused))
+
+		if x < end {
+			text.WriteAt(y, x, breakpointGlyph(bp))
+			colors.SetColor(y, x, 1, breakpointColor(bp))
+		}
+		x += 2
+
+		cols := []string{
+			fmt.Sprintf("%*d", idWidth, bp.ID),
+			fmt.Sprintf("%-*s", locWidth, breakpointLocation(bp)),
+		}
+		if bp.Cond != "" {
+			cols = append(cols, "if "+bp.Cond)
+		}
+		if bp.HitCond != "" {
+			cols = append(cols, "hits "+bp.HitCond)
+		}
+		if hits := breakpointHits(bp); hits != "" {
+			cols = append(cols, hits)
+		}
+		line := strings.Join(cols, "  ")
+		line = line[:min(len(line), max(0, end-x))]
+		switch {
+		case bp.Disabled:
+			colors.SetColor(y, x, len(line), frame.ColorFGBlack)
+		case i == b.LineCursor && b.Focused:
+			colors.SetColor(y, x, len(line), frame.ColorFGWhite)
+		}
+		text.WriteString(y, x, line)
+	}
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/dlv"
+	"github.com/philippta/godbg/frame"
+)
+
+type Crash struct {
+	Focused bool
+	Size    Size
+	Message string
+	Value   Variables
+}
+
+func (c *Crash) Resize(w, h int) {
+	c.Size.Width, c.Size.Height = w, h
+	c.Value.Resize(w, max(0, h-2))
+	c.Value.AlignCursor()
+}
+
+// Load shows the panic value or fatal error message of crash, expanded
+// one level.
+func (c *Crash) Load(crash *dlv.Crash) {
+	label := "panic"
+	if crash.Fatal {
+		label = "fatal error"
+	}
+	val := crash.Value
+	val.Name = label
+
+	c.Value.Expanded = map[string]bool{label: true}
+	c.Value.Load(nil, []api.Variable{val})
+	c.Value.ResetCursor(c.Size.Height)
+
+	c.Message = label + ": " + c.Value.Variables[0].Value
+	if u := c.Value.Variables[0].Unreadable; u != "" {
+		c.Message = label + ": <" + u + ">"
+	}
+}
+
+func (c *Crash) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	renderTitle(text, colors, offsetY, offsetX, c.Size.Width, " Crash ", c.Focused)
+	if c.Size.Height < 2 {
+		return
+	}
+
+	msg := c.Message[:min(len(c.Message), max(0, c.Size.Width-3))]
+	colors.SetColor(offsetY+1, offsetX+3, len(msg), frame.ColorFGRed)
+	text.WriteString(offsetY+1, offsetX+3, msg)
+
+	c.Value.Focused = c.Focused
+	c.Value.RenderFrame(text, colors, offsetY+2, offsetX)
+}
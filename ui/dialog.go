@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"github.com/philippta/godbg/frame"
+)
+
+type Dialog struct {
+	Title string
+	Lines []string
+}
+
+func (d *Dialog) Size() Size {
+	w := len(d.Title) + 4
+	for _, l := range d.Lines {
+		w = max(w, len(l)+4)
+	}
+	return Size{Width: w, Height: len(d.Lines) + 2}
+}
+
+func (d *Dialog) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	size := d.Size()
+	size.Width = min(size.Width, text.Cols-offsetX)
+	size.Height = min(size.Height, text.Rows-offsetY)
+	if size.Width < 2 || size.Height < 2 {
+		return
+	}
+
+	drawBox(text, colors, offsetY, offsetX, size.Width, size.Height, frame.ColorFGBlue)
+	if d.Title != "" {
+		text.WriteString(offsetY, offsetX+2, d.Title[:min(len(d.Title), size.Width-4)])
+		colors.SetColor(offsetY, offsetX+2, min(len(d.Title), size.Width-4), frame.ColorFGWhite)
+	}
+
+	for i, line := range d.Lines {
+		if i >= size.Height-2 {
+			break
+		}
+		text.WriteString(offsetY+i+1, offsetX+2, line[:min(len(line), size.Width-4)])
+	}
+}
+
+func drawBox(text, colors *frame.Frame, y, x, w, h int, color rune) {
+	text.FillSpaceRegion(y, x, w, h)
+	colors.FillZeroesRegion(y, x, w, h)
+
+	for i := 1; i < w-1; i++ {
+		text.WriteAt(y, x+i, '─')
+		text.WriteAt(y+h-1, x+i, '─')
+	}
+	for i := 1; i < h-1; i++ {
+		text.WriteAt(y+i, x, '│')
+		text.WriteAt(y+i, x+w-1, '│')
+		colors.SetColor(y+i, x, 1, color)
+		colors.SetColor(y+i, x+w-1, 1, color)
+	}
+	text.WriteAt(y, x, '┌')
+	text.WriteAt(y, x+w-1, '┐')
+	text.WriteAt(y+h-1, x, '└')
+	text.WriteAt(y+h-1, x+w-1, '┘')
+	colors.SetColor(y, x, w, color)
+	colors.SetColor(y+h-1, x, w, color)
+}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/frame"
+)
+
+type Disassembly struct {
+	Focused      bool
+	Size         Size
+	Instructions []api.AsmInstruction
+	Rows         []asmRow
+	Function     string
+	LineCursor   int
+	LineStart    int
+	// CallerFrame is set when a frame other than the topmost one is
+	// selected, so the PC marks a return address.
+	CallerFrame bool
+}
+
+// asmRow is either an instruction or, if inst is -1, the source line the
+// instructions below it were compiled from.
+type asmRow struct {
+	inst int
+	text string
+}
+
+func (d *Disassembly) Resize(w, h int) {
+	d.Size.Width, d.Size.Height = w, h
+	d.AlignCursor()
+}
+
+// Load replaces the instructions, interleaves them with their source
+// lines and centers the cursor on the PC.
+func (d *Disassembly) Load(insts []api.AsmInstruction) {
+	d.Instructions = insts
+	d.Rows = d.Rows[:0]
+	d.Function = ""
+	d.LineCursor = 0
+
+	var (
+		sources = map[string][][]byte{}
+		file    string
+		line    int
+	)
+	for i, inst := range insts {
+		if d.Function == "" && inst.Loc.Function != nil {
+			d.Function = inst.Loc.Function.Name()
+		}
+		if inst.Loc.File != file || inst.Loc.Line != line {
+			file, line = inst.Loc.File, inst.Loc.Line
+			if src, ok := sourceLine(sources, file, line); ok {
+				d.Rows = append(d.Rows, asmRow{inst: -1, text: src})
+			}
+		}
+		if inst.AtPC {
+			d.LineCursor = len(d.Rows)
+		}
+		d.Rows = append(d.Rows, asmRow{inst: i, text: fmt.Sprintf("%#x  %s", inst.Loc.PC, inst.Text)})
+	}
+	d.CenterCursor()
+}
+
+// sourceLine returns the source line at file and line prefixed with its
+// location, or false if the source is not available. Files are read once
+// and kept in sources.
+func sourceLine(sources map[string][][]byte, file string, line int) (string, bool) {
+	lines, ok := sources[file]
+	if !ok {
+		lines, _ = readSource(file)
+		sources[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d  %s", filepath.Base(file), line, strings.TrimSpace(string(lines[line-1]))), true
+}
+
+// Selected returns the instruction under the cursor, or the first one
+// below the source line under the cursor.
+func (d *Disassembly) Selected() (api.AsmInstruction, bool) {
+	for i := d.LineCursor; i < len(d.Rows); i++ {
+		if n := d.Rows[i].inst; n >= 0 {
+			return d.Instructions[n], true
+		}
+	}
+	return api.AsmInstruction{}, false
+}
+
+func (d *Disassembly) MoveUp() {
+	d.LineCursor = max(0, d.LineCursor-1)
+	d.AlignCursor()
+}
+
+func (d *Disassembly) MoveDown() {
+	d.LineCursor = min(d.LineCursor+1, max(0, len(d.Rows)-1))
+	d.AlignCursor()
+}
+
+func (d *Disassembly) AlignCursor() {
+	height := d.Size.Height - 1
+	d.LineCursor = min(d.LineCursor, max(0, len(d.Rows)-1))
+	if d.LineCursor < d.LineStart {
+		d.LineStart = d.LineCursor
+	}
+	if d.LineCursor > d.LineStart+height-1 {
+		d.LineStart = d.LineCursor - height + 1
+	}
+	d.LineStart = max(0, min(d.LineStart, len(d.Rows)-height))
+}
+
+func (d *Disassembly) CenterCursor() {
+	height := d.Size.Height - 1
+	d.LineStart = max(0, min(d.LineCursor-height/2, len(d.Rows)-height))
+}
+
+func (d *Disassembly) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	x := renderTitle(text, colors, offsetY, offsetX, d.Size.Width, " Disassembly ", d.Focused)
+	if d.Function != "" {
+		label := " " + d.Function + " "
+		label = label[:min(len(label), max(0, offsetX+d.Size.Width-x-1))]
+		colors.SetColor(offsetY, x+1, len(label), frame.ColorFGBlue)
+		text.WriteString(offsetY, x+1, label)
+	}
+
+	lineEnd := min(d.LineStart+d.Size.Height-1, len(d.Rows))
+	for i := d.LineStart; i < lineEnd; i++ {
+		y := i - d.LineStart + offsetY + 1
+		x := offsetX
+		row := d.Rows[i]
+
+		if row.inst < 0 {
+			if i == d.LineCursor {
+				text.WriteString(y, x, "=> ")
+				colors.SetColor(y, x, 3, cursorColor(d.Focused))
+			}
+			x += 5
+			src := row.text[:min(len(row.text), max(0, offsetX+d.Size.Width-x))]
+			colors.SetColor(y, x, len(src), frame.ColorFGBlue)
+			text.WriteString(y, x, src)
+			continue
+		}
+
+		inst := d.Instructions[row.inst]
+		switch {
+		case inst.AtPC && d.CallerFrame:
+			x = text.WriteString(y, x, "-> ")
+		case i == d.LineCursor || inst.AtPC:
+			x = text.WriteString(y, x, "=> ")
+		default:
+			x = text.WriteString(y, x, "   ")
+		}
+		switch {
+		case !d.Focused || i == d.LineCursor:
+			colors.SetColor(y, offsetX, 3, cursorColor(d.Focused))
+		case inst.AtPC && d.CallerFrame:
+			colors.SetColor(y, offsetX, 3, frame.ColorFGBlue)
+		case inst.AtPC:
+			colors.SetColor(y, offsetX, 3, frame.ColorFGYellow)
+		}
+
+		if inst.Breakpoint {
+			colors.SetColor(y, x, 1, frame.ColorFGRed)
+			text.WriteAt(y, x, '*')
+		}
+		x += 2
+
+		line := row.text[:min(len(row.text), max(0, offsetX+d.Size.Width-x))]
+		if i == d.LineCursor && d.Focused {
+			colors.SetColor(y, x, len(line), frame.ColorFGWhite)
+		}
+		text.WriteString(y, x, line)
+	}
+}
+
+func cursorColor(focused bool) rune {
+	if focused {
+		return frame.ColorFGGreen
+	}
+	return frame.ColorFGBlack
+}
@@ -3,6 +3,8 @@ package ui
 import (
 	"os"
 	"testing"
+
+	"github.com/philippta/godbg/frame"
 )
 
 func TestFilesRender(t *testing.T) {
@@ -11,6 +13,8 @@ func TestFilesRender(t *testing.T) {
 		Search: "main.go",
 	}
 
-	text, colors := f.RenderFrame()
+	text, colors := frame.New(25, 70), frame.New(25, 70)
+	text.FillSpace()
+	f.RenderFrame(text, colors, 0, 0)
 	text.PrintLinesColored(os.Stdout, colors)
 }
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/philippta/godbg/fuzzy"
+)
+
+// funcScope restricts the functions listed in the picker by where their
+// package comes from.
+type funcScope int
+
+const (
+	scopeAll funcScope = iota
+	scopeModule
+	scopeDeps
+	scopeCount
+)
+
+var scopeLabels = [scopeCount]string{"all", "module", "stdlib and deps"}
+
+// Functions is a picker for setting breakpoints on functions. The search
+// is fuzzy, unless it starts with '/', which makes the rest a regular
+// expression that selects all functions it matches.
+type Functions struct {
+	Picker
+	Module string
+	Scope  funcScope
+	Names  []string
+}
+
+// Load replaces the listed functions. Functions generated by the
+// compiler, like type:.eq.T, are left out.
+func (f *Functions) Load(names []string) {
+	f.Names = f.Names[:0]
+	for _, name := range names {
+		if !strings.Contains(name, ":") {
+			f.Names = append(f.Names, name)
+		}
+	}
+	f.Match = f.match
+	f.setScope(f.Scope)
+	f.Reset()
+}
+
+// IsRegexp reports whether the search is a regular expression.
+func (f *Functions) IsRegexp() bool {
+	return strings.HasPrefix(f.Search, "/")
+}
+
+// Selected returns the function under the cursor.
+func (f *Functions) Selected() (string, bool) {
+	i, ok := f.Picker.Selected()
+	if !ok {
+		return "", false
+	}
+	return f.Items[i], true
+}
+
+func (f *Functions) HandleInput(key rune, more []rune) {
+	if key != '\t' {
+		f.Picker.HandleInput(key, more)
+		return
+	}
+	// Next scope
+	f.setScope((f.Scope + 1) % scopeCount)
+	f.Cursor = 0
+	f.Filter()
+}
+
+func (f *Functions) setScope(scope funcScope) {
+	f.Scope = scope
+	f.Title = "Functions: " + scopeLabels[scope]
+	f.Items = f.inScope()
+}
+
+func (f *Functions) match(names []string, search string) ([]int, error) {
+	f.MarkAll = f.IsRegexp()
+	if !f.MarkAll {
+		return fuzzy.Filter(names, search), nil
+	}
+	re, err := funcRegexp(strings.TrimSuffix(search[1:], "/"))
+	if err != nil {
+		return nil, err
+	}
+	var matches []int
+	for i, name := range names {
+		if re.MatchString(name) {
+			matches = append(matches, i)
+		}
+	}
+	return matches, nil
+}
+
+func (f *Functions) inScope() []string {
+	if f.Scope == scopeAll {
+		return f.Names
+	}
+	var names []string
+	for _, name := range f.Names {
+		if f.inModule(name) == (f.Scope == scopeModule) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// inModule reports whether the function name belongs to a package of the
+// main module.
+func (f *Functions) inModule(name string) bool {
+	if strings.HasPrefix(name, "main.") {
+		return true
+	}
+	return f.Module != "" && (strings.HasPrefix(name, f.Module+".") || strings.HasPrefix(name, f.Module+"/"))
+}
+
+// receiverPattern matches the receiver of a method name, like (*Server).
+// in mypkg.(*Server).Handle.
+var receiverPattern = regexp.MustCompile(`\(\*?\w+\)\.`)
+
+// funcRegexp compiles a function name pattern. Receivers of methods match
+// literally, other parentheses group as usual.
+func funcRegexp(expr string) (*regexp.Regexp, error) {
+	expr = receiverPattern.ReplaceAllStringFunc(expr, regexp.QuoteMeta)
+	return regexp.Compile(expr)
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFunctionsFilter(t *testing.T) {
+	f := &Functions{Module: "example.com/app"}
+	f.Resize(60, 20)
+	f.Load([]string{"main.main", "example.com/app/store.(*DB).Get", "fmt.Println", "type:.eq.main.T"})
+
+	for _, key := range "/Get|Print" {
+		f.HandleInput(key, nil)
+	}
+	if got, want := f.Matches(), []string{"example.com/app/store.(*DB).Get", "fmt.Println"}; !slices.Equal(got, want) {
+		t.Errorf("matches = %v, want %v", got, want)
+	}
+	if !f.MarkAll {
+		t.Error("regular expression does not mark all matches")
+	}
+
+	f.HandleInput('\t', nil) // module
+	if got, want := f.Matches(), []string{"example.com/app/store.(*DB).Get"}; !slices.Equal(got, want) {
+		t.Errorf("module matches = %v, want %v", got, want)
+	}
+	if f.Title != "Functions: module" {
+		t.Errorf("title = %q", f.Title)
+	}
+}
+
+func TestFuncRegexp(t *testing.T) {
+	tests := []struct {
+		expr  string
+		match []string
+		miss  []string
+	}{
+		{`store.(*DB).Get`, []string{"app/store.(*DB).Get"}, []string{"app/store.DB.Get"}},
+		{`store.(DB).Get`, []string{"app/store.(DB).Get"}, []string{"app/store.(*DB).Get"}},
+		{`\.(Get|Put)$`, []string{"app/store.(*DB).Get", "app/store.Put"}, []string{"app/store.Delete"}},
+		{`(?i)^main\.RUN`, []string{"main.run"}, []string{"main.main"}},
+	}
+	for _, tt := range tests {
+		re, err := funcRegexp(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		for _, name := range tt.match {
+			if !re.MatchString(name) {
+				t.Errorf("%s does not match %s", tt.expr, name)
+			}
+		}
+		for _, name := range tt.miss {
+			if re.MatchString(name) {
+				t.Errorf("%s matches %s", tt.expr, name)
+			}
+		}
+	}
+}
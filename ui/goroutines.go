@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/frame"
+	"github.com/philippta/godbg/fuzzy"
+)
+
+type Goroutines struct {
+	Focused    bool
+	Size       Size
+	Goroutines []*api.Goroutine
+	Rows       []string
+	Filtered   []int
+	Current    int64
+	LineCursor int
+	LineStart  int
+	Filter     string
+	Filtering  bool
+	Prompt     Prompt
+}
+
+func (g *Goroutines) Resize(w, h int) {
+	g.Size.Width, g.Size.Height = w, h
+	g.AlignCursor()
+}
+
+// Load replaces the listed goroutines. The cursor stays on the goroutine
+// it was on, or moves to the current one.
+func (g *Goroutines) Load(gs []*api.Goroutine, current int64) {
+	id := current
+	if sel, ok := g.Selected(); ok && current == g.Current {
+		id = sel.ID
+	}
+
+	g.Goroutines = gs
+	g.Current = current
+	g.Rows = make([]string, len(gs))
+	for i, gr := range gs {
+		g.Rows[i] = goroutineRow(gr)
+	}
+	g.filter()
+
+	for i, n := range g.Filtered {
+		if g.Goroutines[n].ID == id {
+			g.LineCursor = i
+		}
+	}
+	g.AlignCursor()
+}
+
+func (g *Goroutines) filter() {
+	g.Filtered = fuzzy.Filter(g.Rows, g.Filter)
+}
+
+// Selected returns the goroutine under the cursor.
+func (g *Goroutines) Selected() (*api.Goroutine, bool) {
+	if g.LineCursor >= len(g.Filtered) {
+		return nil, false
+	}
+	return g.Goroutines[g.Filtered[g.LineCursor]], true
+}
+
+func (g *Goroutines) MoveUp() {
+	g.LineCursor = max(0, g.LineCursor-1)
+	g.AlignCursor()
+}
+
+func (g *Goroutines) MoveDown() {
+	g.LineCursor = min(g.LineCursor+1, max(0, len(g.Filtered)-1))
+	g.AlignCursor()
+}
+
+func (g *Goroutines) AlignCursor() {
+	height := g.Size.Height - 1
+	g.LineCursor = min(g.LineCursor, max(0, len(g.Filtered)-1))
+	if g.LineCursor < g.LineStart {
+		g.LineStart = g.LineCursor
+	}
+	if g.LineCursor > g.LineStart+height-1 {
+		g.LineStart = g.LineCursor - height + 1
+	}
+	g.LineStart = max(0, min(g.LineStart, len(g.Filtered)-height))
+}
+
+func (g *Goroutines) StartFilter() {
+	g.Filtering = true
+	g.Prompt.Reset("/", g.Filter)
+}
+
+// HandleFilterInput edits the filter, narrowing the list while typing.
+func (g *Goroutines) HandleFilterInput(key rune, more []rune) {
+	switch g.Prompt.HandleInput(key, more) {
+	case PromptSubmit:
+		g.Filtering = false
+	case PromptCancel:
+		g.Filtering = false
+		g.Prompt.Reset("/", "")
+	}
+	g.Filter = g.Prompt.Text()
+	g.filter()
+	g.LineCursor = 0
+	g.AlignCursor()
+}
+
+func goroutineRow(g *api.Goroutine) string {
+	status := goroutineStatus(g)
+	return fmt.Sprintf("%d  %s  %s  start %s", g.ID, status, formatLocation(g.UserCurrentLoc), locationFunction(g.StartLoc))
+}
+
+var goroutineStatuses = [...]string{"idle", "runnable", "running", "syscall", "waiting", "moribund", "dead", "enqueue", "copystack"}
+
+// waitReasons are the runtime's descriptions of why a goroutine waits.
+var waitReasons = [...]string{
+	"",
+	"GC assist marking",
+	"IO wait",
+	"chan receive (nil chan)",
+	"chan send (nil chan)",
+	"dumping heap",
+	"garbage collection",
+	"garbage collection scan",
+	"panicwait",
+	"select",
+	"select (no cases)",
+	"GC assist wait",
+	"GC sweep wait",
+	"GC scavenge wait",
+	"chan receive",
+	"chan send",
+	"finalizer wait",
+	"force gc (idle)",
+	"semacquire",
+	"sleep",
+	"sync.Cond.Wait",
+	"timer goroutine (idle)",
+	"trace reader (blocked)",
+	"wait for GC cycle",
+	"GC worker (idle)",
+	"preempted",
+	"debug call",
+	"GC mark termination",
+	"stopping the world",
+	"flushing proc caches",
+	"trace goroutine status",
+	"trace proc status",
+	"page trace flush",
+	"coroutine",
+}
+
+func goroutineStatus(g *api.Goroutine) string {
+	status := "unknown"
+	if g.Status < uint64(len(goroutineStatuses)) {
+		status = goroutineStatuses[g.Status]
+	}
+	if (g.Status == api.GoroutineWaiting || g.Status == api.GoroutineSyscall) && g.WaitReason > 0 && g.WaitReason < int64(len(waitReasons)) {
+		status += " (" + waitReasons[g.WaitReason] + ")"
+	}
+	return status
+}
+
+func formatLocation(loc api.Location) string {
+	return fmt.Sprintf("%s:%d %s", filepath.Base(loc.File), loc.Line, locationFunction(loc))
+}
+
+func locationFunction(loc api.Location) string {
+	if loc.Function == nil {
+		return "?"
+	}
+	return loc.Function.Name()
+}
+
+const goroutinesTitle = " Goroutines "
+
+func (g *Goroutines) CursorPosition() (y, x int) {
+	return 0, 1 + len(goroutinesTitle) + 1 + g.Prompt.CursorPosition(g.promptWidth())
+}
+
+func (g *Goroutines) promptWidth() int {
+	return g.Size.Width - len(goroutinesTitle) - 3
+}
+
+func (g *Goroutines) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	x := renderTitle(text, colors, offsetY, offsetX, g.Size.Width, goroutinesTitle, g.Focused)
+
+	if g.Filtering && g.promptWidth() > 0 {
+		x = text.WriteString(offsetY, x, " ")
+		text.FillSpaceRegion(offsetY, x, g.promptWidth(), 1)
+		g.Prompt.RenderFrame(text, colors, offsetY, x, g.promptWidth())
+	} else if g.Filter != "" {
+		filter := " /" + g.Filter + " "
+		filter = filter[:min(len(filter), max(0, offsetX+g.Size.Width-x-1))]
+		colors.SetColor(offsetY, x+1, len(filter), frame.ColorFGBlue)
+		text.WriteString(offsetY, x+1, filter)
+	}
+
+	lineEnd := min(g.LineStart+g.Size.Height-1, len(g.Filtered))
+	for i := g.LineStart; i < lineEnd; i++ {
+		y := i - g.LineStart + offsetY + 1
+		x := offsetX
+		gr := g.Goroutines[g.Filtered[i]]
+
+		if i == g.LineCursor {
+			x = text.WriteString(y, x, "=> ")
+		} else {
+			x = text.WriteString(y, x, "   ")
+		}
+		if g.Focused {
+			colors.SetColor(y, offsetX, 3, frame.ColorFGGreen)
+		} else {
+			colors.SetColor(y, offsetX, 3, frame.ColorFGBlack)
+		}
+
+		// The goroutine shown in the Source and Variables panes.
+		if gr.ID == g.Current {
+			colors.SetColor(y, x, 1, frame.ColorFGYellow)
+			text.WriteString(y, x, "*")
+		}
+		x++
+
+		row := g.Rows[g.Filtered[i]]
+		row = row[:min(len(row), max(0, offsetX+g.Size.Width-x))]
+		switch {
+		case i == g.LineCursor && g.Focused:
+			colors.SetColor(y, x, len(row), frame.ColorFGWhite)
+		case strings.HasPrefix(locationFunction(gr.UserCurrentLoc), "runtime."):
+			colors.SetColor(y, x, len(row), frame.ColorFGBlack)
+		}
+		text.WriteString(y, x, row)
+	}
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/philippta/godbg/dlv"
+	"github.com/philippta/godbg/frame"
+)
+
+type Output struct {
+	Title      string
+	Focused    bool
+	Size       Size
+	Lines      []dlv.OutputLine
+	LineCursor int
+	LineStart  int
+	Search     string
+	Searching  bool
+	Prompt     Prompt
+}
+
+func (o *Output) Resize(w, h int) {
+	o.Size.Width, o.Size.Height = w, h
+	o.AlignCursor()
+}
+
+func (o *Output) Load(lines []dlv.OutputLine) {
+	follow := o.LineCursor >= len(o.Lines)-1
+	o.Lines = lines
+	if follow {
+		o.LineCursor = max(0, len(o.Lines)-1)
+	}
+	o.AlignCursor()
+}
+
+func (o *Output) MoveUp() {
+	o.LineCursor = max(0, o.LineCursor-1)
+	o.AlignCursor()
+}
+
+func (o *Output) MoveDown() {
+	o.LineCursor = min(o.LineCursor+1, max(0, len(o.Lines)-1))
+	o.AlignCursor()
+}
+
+func (o *Output) MoveTop() {
+	o.LineCursor = 0
+	o.AlignCursor()
+}
+
+func (o *Output) MoveBottom() {
+	o.LineCursor = max(0, len(o.Lines)-1)
+	o.AlignCursor()
+}
+
+func (o *Output) AlignCursor() {
+	height := o.Size.Height - 1
+	o.LineCursor = min(o.LineCursor, max(0, len(o.Lines)-1))
+	if o.LineCursor < o.LineStart {
+		o.LineStart = o.LineCursor
+	}
+	if o.LineCursor > o.LineStart+height-1 {
+		o.LineStart = o.LineCursor - height + 1
+	}
+	o.LineStart = max(0, min(o.LineStart, len(o.Lines)-height))
+}
+
+func (o *Output) StartSearch() {
+	o.Searching = true
+	o.Prompt.Reset("/", o.Search)
+}
+
+func (o *Output) HandleSearchInput(key rune, more []rune) {
+	switch o.Prompt.HandleInput(key, more) {
+	case PromptSubmit:
+		o.Searching = false
+		o.Search = o.Prompt.Text()
+		o.NextMatch(0)
+	case PromptCancel:
+		o.Searching = false
+	}
+}
+
+// NextMatch moves the cursor to the next line containing the search term
+// in the given direction. A direction of 0 includes the current line.
+func (o *Output) NextMatch(dir int) {
+	if o.Search == "" || len(o.Lines) == 0 {
+		return
+	}
+	step := dir
+	if step == 0 {
+		step = 1
+	}
+	for i := 0; i < len(o.Lines); i++ {
+		n := (o.LineCursor + dir + i*step + 2*len(o.Lines)) % len(o.Lines)
+		if strings.Contains(o.Lines[n].Text, o.Search) {
+			o.LineCursor = n
+			o.AlignCursor()
+			return
+		}
+	}
+}
+
+func (o *Output) title() string {
+	if o.Title == "" {
+		return " Output "
+	}
+	return " " + o.Title + " "
+}
+
+func (o *Output) CursorPosition() (y, x int) {
+	return 0, 1 + len(o.title()) + 1 + o.Prompt.CursorPosition(o.promptWidth())
+}
+
+func (o *Output) promptWidth() int {
+	return o.Size.Width - len(o.title()) - 3
+}
+
+func (o *Output) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	x := renderTitle(text, colors, offsetY, offsetX, o.Size.Width, o.title(), o.Focused)
+
+	if o.Searching && o.promptWidth() > 0 {
+		x = text.WriteString(offsetY, x, " ")
+		text.FillSpaceRegion(offsetY, x, o.promptWidth(), 1)
+		o.Prompt.RenderFrame(text, colors, offsetY, x, o.promptWidth())
+	} else if o.Search != "" {
+		search := " /" + o.Search + " "
+		colors.SetColor(offsetY, x+1, min(len(search), offsetX+o.Size.Width-x-1), frame.ColorFGBlue)
+		text.WriteString(offsetY, x+1, search[:min(len(search), max(0, offsetX+o.Size.Width-x-1))])
+	}
+
+	lineEnd := min(o.LineStart+o.Size.Height-1, len(o.Lines))
+	for i := o.LineStart; i < lineEnd; i++ {
+		y := i - o.LineStart + offsetY + 1
+		x := offsetX
+
+		if i == o.LineCursor {
+			x = text.WriteString(y, x, "=> ")
+		} else {
+			x = text.WriteString(y, x, "   ")
+		}
+		if !o.Focused {
+			colors.SetColor(y, offsetX, 3, frame.ColorFGBlack)
+		} else {
+			colors.SetColor(y, offsetX, 3, frame.ColorFGGreen)
+		}
+
+		line := strings.ReplaceAll(o.Lines[i].Text, "\t", "    ")
+		line = line[:min(len(line), max(0, offsetX+o.Size.Width-x))]
+		if o.Lines[i].Stream == dlv.Stderr {
+			colors.SetColor(y, x, len(line), frame.ColorFGRed)
+		} else if o.Lines[i].Stream == dlv.Stdin {
+			colors.SetColor(y, x, len(line), frame.ColorFGBlue)
+		} else if i == o.LineCursor && o.Focused {
+			colors.SetColor(y, x, len(line), frame.ColorFGWhite)
+		}
+		text.WriteString(y, x, line)
+
+		if o.Search == "" {
+			continue
+		}
+		for start := 0; ; {
+			n := strings.Index(line[start:], o.Search)
+			if n < 0 {
+				break
+			}
+			colors.SetColor(y, x+start+n, len(o.Search), frame.ColorFGYellow)
+			start += n + len(o.Search)
+		}
+	}
+}
+
+// renderTitle draws the horizontal line with the title at the top of a
+// pane and returns the column after the title.
+func renderTitle(text, colors *frame.Frame, y, x, width int, title string, focused bool) int {
+	for i := 0; i < width; i++ {
+		text.WriteAt(y, x+i, '─')
+	}
+	colors.SetColor(y, x, width, frame.ColorFGBlack)
+
+	if focused {
+		colors.SetColor(y, x+1, len(title), frame.ColorFGGreen)
+	}
+	return text.WriteString(y, x+1, title)
+}
+
+// logLines formats logpoint messages for display in an Output pane.
+func logLines(entries []dlv.LogEntry) []dlv.OutputLine {
+	lines := make([]dlv.OutputLine, len(entries))
+	for i, e := range entries {
+		lines[i] = dlv.OutputLine{
+			Stream: dlv.Stdout,
+			Text:   fmt.Sprintf("%s g%d %s:%d  %s", e.Time.Format("15:04:05.000"), e.GoroutineID, filepath.Base(e.File), e.Line, e.Message),
+		}
+	}
+	return lines
+}
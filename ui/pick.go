@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mattn/go-tty"
+	"github.com/philippta/godbg/frame"
+	"github.com/philippta/godbg/term"
+)
+
+// Pick lets the user choose one of items before the debugger starts and
+// returns its index. It reports false if the choice was canceled.
+func Pick(title string, items []string) (int, bool) {
+	tty, err := tty.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tty.Close()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	// Keys are read only when the loop asks for them. Closing the tty
+	// does not end a pending read, so a reader that kept reading would
+	// take the first key meant for the debugger.
+	next := make(chan struct{})
+	defer close(next)
+	keys := make(chan []rune, 1)
+	go func() {
+		for range next {
+			key, err := tty.ReadRune()
+			if err != nil {
+				close(keys)
+				return
+			}
+			input := []rune{key}
+			for tty.Buffered() {
+				key, _ := tty.ReadRune()
+				input = append(input, key)
+			}
+			keys <- input
+		}
+	}()
+
+	out := tty.Output()
+	out.Write(term.AltScreen)
+	defer func() {
+		out.Write(term.ShowCursor)
+		out.Write(term.ExitAltScreen)
+	}()
+
+	p := &Picker{Title: title, Items: items}
+	p.Filter()
+	for {
+		w, h, _ := tty.Size()
+		p.paint(out, w, h)
+
+		next <- struct{}{}
+		select {
+		case <-sigs:
+			return 0, false
+		case input, ok := <-keys:
+			if !ok {
+				return 0, false
+			}
+			switch input[0] {
+			case 13: // Enter
+				return p.Selected()
+			case 27: // ESC
+				if len(input) == 1 {
+					return 0, false
+				}
+			}
+			p.HandleInput(input[0], input[1:])
+		}
+	}
+}
+
+func (p *Picker) paint(out *os.File, w, h int) {
+	text := frame.New(h, w)
+	text.FillSpace()
+	colors := frame.New(h, w)
+
+	p.Size = Size{Width: min(w, 80), Height: min(h, max(5, len(p.Items)+4))}
+	y, x := max(0, (h-p.Size.Height)/2), max(0, (w-p.Size.Width)/2)
+	p.RenderFrame(text, colors, y, x)
+
+	out.Write(term.HideCursor)
+	out.Write(term.ResetCursor)
+	text.PrintColored(out, colors)
+	cy, cx := p.CursorPosition()
+	out.Write(term.ShowCursor)
+	out.Write(term.PositionCursor(cy+y, cx+x))
+}
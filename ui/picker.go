@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/philippta/godbg/frame"
+	"github.com/philippta/godbg/fuzzy"
+)
+
+// Picker is a popup list to choose items from by typing a search. The
+// search is fuzzy, unless Match is set.
+type Picker struct {
+	Size         Size
+	Title        string
+	Items        []string
+	Search       string
+	SearchCursor int
+	Cursor       int
+	// Filtered holds the indices of the items matching the search.
+	Filtered []int
+	Err      error
+	// Match returns the indices of the items matching search.
+	Match func(items []string, search string) ([]int, error)
+	// MarkAll marks every matching item as selected, instead of the one
+	// under the cursor.
+	MarkAll bool
+}
+
+func (p *Picker) Resize(w, h int) {
+	p.Size.Width, p.Size.Height = w, h
+}
+
+func (p *Picker) CursorPosition() (y, x int) {
+	return 2, p.SearchCursor + 3
+}
+
+func (p *Picker) Reset() {
+	p.Cursor = 0
+	p.SearchCursor = 0
+	p.Search = ""
+	p.Filter()
+}
+
+// Selected returns the index of the item under the cursor.
+func (p *Picker) Selected() (int, bool) {
+	if p.Cursor >= len(p.Filtered) {
+		return 0, false
+	}
+	return p.Filtered[p.Cursor], true
+}
+
+// Matches returns the items matching the search.
+func (p *Picker) Matches() []string {
+	items := make([]string, len(p.Filtered))
+	for i, j := range p.Filtered {
+		items[i] = p.Items[j]
+	}
+	return items
+}
+
+func (p *Picker) HandleInput(key rune, more []rune) {
+	switch key {
+	case 127: // DEL
+		p.Search = p.Search[:max(0, len(p.Search)-1)]
+	case 27: // ESC
+		if len(more) != 2 || more[0] != 91 { // Arrow
+			return
+		}
+		switch more[1] {
+		case 65: // Up
+			p.Cursor = max(0, p.Cursor-1)
+		case 66: // Down
+			p.Cursor = min(p.Cursor+1, max(0, len(p.Filtered)-1))
+		}
+		return
+	default:
+		if key < ' ' {
+			return
+		}
+		p.Search += string(key)
+	}
+	p.SearchCursor = min(len(p.Search), max(0, p.Size.Width-4))
+	p.Cursor = 0
+	p.Filter()
+}
+
+func (p *Picker) Filter() {
+	p.Err = nil
+	if p.Match == nil {
+		p.Filtered = fuzzy.Filter(p.Items, p.Search)
+	} else {
+		p.Filtered, p.Err = p.Match(p.Items, p.Search)
+	}
+	p.Cursor = min(p.Cursor, max(0, len(p.Filtered)-1))
+}
+
+func (p *Picker) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	y, x := offsetY, offsetX
+	drawBox(text, colors, y, x, p.Size.Width, p.Size.Height, frame.ColorFGBlue)
+
+	title := " " + p.Title + " "
+	title = title[:min(len(title), max(0, p.Size.Width-4))]
+	colors.SetColor(y, x+2, len(title), frame.ColorFGWhite)
+	text.WriteString(y, x+2, title)
+
+	// Search Box
+	text.WriteAt(y+2, x, '├')
+	text.WriteAt(y+2, x+p.Size.Width-1, '┤')
+	for i := 1; i < p.Size.Width-1; i++ {
+		text.WriteAt(y+2, x+i, '─')
+	}
+	colors.SetColor(y+2, x, p.Size.Width, frame.ColorFGBlue)
+
+	searchBoxWidth := p.Size.Width - 4
+	searchTerm := p.Search
+	if len(searchTerm) > searchBoxWidth {
+		searchTerm = searchTerm[len(searchTerm)-searchBoxWidth:]
+	}
+	text.WriteString(y+1, x+2, searchTerm)
+
+	if p.Err != nil {
+		msg := p.Err.Error()
+		msg = msg[:min(len(msg), max(0, p.Size.Width-4))]
+		colors.SetColor(y+3, x+2, len(msg), frame.ColorFGRed)
+		text.WriteString(y+3, x+2, msg)
+		return
+	}
+
+	listHeight := p.Size.Height - 4
+	start := max(0, p.Cursor-listHeight+1)
+	for i := start; i < len(p.Filtered) && i < start+listHeight; i++ {
+		item := p.Items[p.Filtered[i]]
+		item = item[:min(len(item), max(0, p.Size.Width-6))]
+		row := y + i - start + 3
+		switch {
+		case p.MarkAll:
+			text.WriteString(row, x+2, "* "+item)
+			colors.SetColor(row, x+2, 1, frame.ColorFGRed)
+		case i == p.Cursor:
+			text.WriteString(row, x+2, "> "+item)
+			colors.SetColor(row, x+2, len(item)+2, frame.ColorFGGreen)
+		default:
+			text.WriteString(row, x+4, item)
+		}
+	}
+
+	count := fmt.Sprintf(" %d ", len(p.Filtered))
+	if cx := x + p.Size.Width - 2 - len(count); cx > x+2+len(title) {
+		text.WriteString(y, cx, count)
+	}
+}
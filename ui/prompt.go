@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"github.com/philippta/godbg/frame"
+)
+
+const (
+	PromptEditing = iota
+	PromptSubmit
+	PromptCancel
+)
+
+// Prompt is a single line text input.
+type Prompt struct {
+	Label  string
+	Input  []rune
+	Cursor int
+}
+
+func (p *Prompt) Reset(label, input string) {
+	p.Label = label
+	p.Input = []rune(input)
+	p.Cursor = len(p.Input)
+}
+
+func (p *Prompt) Text() string {
+	return string(p.Input)
+}
+
+// HandleInput applies a key press and returns whether editing goes on or
+// the input was submitted or cancelled.
+func (p *Prompt) HandleInput(key rune, more []rune) int {
+	switch key {
+	case 13: // Enter
+		return PromptSubmit
+	case 27: // ESC
+		if len(more) == 0 {
+			return PromptCancel
+		}
+		if len(more) == 2 && more[0] == 91 { // Arrow
+			switch more[1] {
+			case 67: // Right
+				p.Cursor = min(p.Cursor+1, len(p.Input))
+			case 68: // Left
+				p.Cursor = max(0, p.Cursor-1)
+			}
+		}
+	case 127: // DEL
+		if p.Cursor > 0 {
+			p.Input = append(p.Input[:p.Cursor-1], p.Input[p.Cursor:]...)
+			p.Cursor--
+		}
+	case 1: // CTRL+A
+		p.Cursor = 0
+	case 5: // CTRL+E
+		p.Cursor = len(p.Input)
+	case 21: // CTRL+U
+		p.Input = p.Input[:0]
+		p.Cursor = 0
+	default:
+		if key < 32 {
+			break
+		}
+		p.Input = append(p.Input[:p.Cursor], append([]rune{key}, p.Input[p.Cursor:]...)...)
+		p.Cursor++
+		for _, r := range more {
+			if r < 32 {
+				continue
+			}
+			p.Input = append(p.Input[:p.Cursor], append([]rune{r}, p.Input[p.Cursor:]...)...)
+			p.Cursor++
+		}
+	}
+	return PromptEditing
+}
+
+func (p *Prompt) RenderFrame(text, colors *frame.Frame, offsetY, offsetX, width int) {
+	label := p.Label[:min(len(p.Label), width)]
+	colors.SetColor(offsetY, offsetX, len(label), frame.ColorFGBlue)
+	x := text.WriteString(offsetY, offsetX, label)
+
+	start, end := p.visible(width)
+	colors.SetColor(offsetY, x, end-start, frame.ColorFGWhite)
+	text.WriteString(offsetY, x, string(p.Input[start:end]))
+}
+
+// CursorPosition returns the column of the cursor relative to the start
+// of the prompt.
+func (p *Prompt) CursorPosition(width int) int {
+	start, _ := p.visible(width)
+	return min(len(p.Label), width) + p.Cursor - start
+}
+
+// visible returns the range of the input that fits into width next to
+// the label while keeping the cursor in view.
+func (p *Prompt) visible(width int) (start, end int) {
+	avail := width - len(p.Label) - 1
+	if avail <= 0 {
+		return 0, 0
+	}
+	start = max(0, p.Cursor-avail)
+	end = min(len(p.Input), start+avail)
+	return start, end
+}
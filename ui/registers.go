@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/frame"
+)
+
+type Registers struct {
+	Focused       bool
+	Size          Size
+	Registers     []api.Register
+	Changed       map[string]bool
+	FloatingPoint bool
+	LineCursor    int
+	LineStart     int
+
+	// The values at the previous stop, which changes are highlighted
+	// against as long as the same goroutine and frame are shown.
+	stop          int
+	scope         registerScope
+	previous      map[string]string
+	previousScope registerScope
+}
+
+type registerScope struct {
+	goroutine int64
+	frame     int
+}
+
+func (r *Registers) Resize(w, h int) {
+	r.Size.Width, r.Size.Height = w, h
+	r.AlignCursor()
+}
+
+// Load replaces the registers shown for the given goroutine and frame.
+// stop counts the stops of the program. When it is one more than at the
+// last load, the registers loaded before are the values of the previous
+// stop.
+func (r *Registers) Load(regs []api.Register, goroutine int64, frame int, stop int) {
+	if stop != r.stop {
+		// Registers loaded before an earlier stop, while the pane was
+		// closed, are not the previous values.
+		r.previous = map[string]string{}
+		if stop == r.stop+1 {
+			for _, reg := range r.Registers {
+				r.previous[reg.Name] = reg.Value
+			}
+		}
+		r.previousScope = r.scope
+		r.stop = stop
+	}
+
+	r.Registers = regs
+	r.scope = registerScope{goroutine, frame}
+	r.Changed = map[string]bool{}
+	if r.scope == r.previousScope {
+		for _, reg := range regs {
+			if prev, ok := r.previous[reg.Name]; ok && prev != reg.Value {
+				r.Changed[reg.Name] = true
+			}
+		}
+	}
+	r.AlignCursor()
+}
+
+func (r *Registers) MoveUp() {
+	r.LineCursor = max(0, r.LineCursor-1)
+	r.AlignCursor()
+}
+
+func (r *Registers) MoveDown() {
+	r.LineCursor = min(r.LineCursor+1, max(0, len(r.Registers)-1))
+	r.AlignCursor()
+}
+
+func (r *Registers) AlignCursor() {
+	height := r.Size.Height - 1
+	r.LineCursor = min(r.LineCursor, max(0, len(r.Registers)-1))
+	if r.LineCursor < r.LineStart {
+		r.LineStart = r.LineCursor
+	}
+	if r.LineCursor > r.LineStart+height-1 {
+		r.LineStart = r.LineCursor - height + 1
+	}
+	r.LineStart = max(0, min(r.LineStart, len(r.Registers)-height))
+}
+
+func (r *Registers) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	x := renderTitle(text, colors, offsetY, offsetX, r.Size.Width, " Registers ", r.Focused)
+	if r.FloatingPoint {
+		label := " floating point "
+		label = label[:min(len(label), max(0, offsetX+r.Size.Width-x-1))]
+		colors.SetColor(offsetY, x+1, len(label), frame.ColorFGBlue)
+		text.WriteString(offsetY, x+1, label)
+	}
+
+	nameWidth := 0
+	for _, reg := range r.Registers {
+		nameWidth = max(nameWidth, len(reg.Name))
+	}
+
+	lineEnd := min(r.LineStart+r.Size.Height-1, len(r.Registers))
+	for i := r.LineStart; i < lineEnd; i++ {
+		y := i - r.LineStart + offsetY + 1
+		x := offsetX
+		reg := r.Registers[i]
+
+		if i == r.LineCursor {
+			x = text.WriteString(y, x, "=> ")
+		} else {
+			x = text.WriteString(y, x, "   ")
+		}
+		colors.SetColor(y, offsetX, 3, cursorColor(r.Focused))
+
+		name := reg.Name[:min(len(reg.Name), max(0, offsetX+r.Size.Width-x))]
+		colors.SetColor(y, x, len(name), frame.ColorFGBlue)
+		text.WriteString(y, x, name)
+		x += nameWidth + 2
+
+		// Vector registers are formatted over several tab separated
+		// fields.
+		value := strings.Join(strings.Fields(reg.Value), " ")
+		value = value[:min(len(value), max(0, offsetX+r.Size.Width-x))]
+		switch {
+		case r.Changed[reg.Name]:
+			colors.SetColor(y, x, len(value), frame.ColorFGYellow)
+		case i == r.LineCursor && r.Focused:
+			colors.SetColor(y, x, len(value), frame.ColorFGWhite)
+		}
+		text.WriteString(y, x, value)
+	}
+}
@@ -0,0 +1,192 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/dlv"
+	"github.com/philippta/godbg/session"
+)
+
+// RestoreBreakpoints sets the breakpoints of the session at path. It is
+// meant for dlv.Options.Init, so that breakpoints in init functions or on
+// the first lines of main are hit. Breakpoints that cannot be set anymore,
+// for example because their line was deleted, are reported.
+func RestoreBreakpoints(dbg dlv.Backend, path string) error {
+	s, err := session.Load(path)
+	if err != nil {
+		return err
+	}
+	return restoreBreakpoints(dbg, s.Breakpoints)
+}
+
+// RestoreSession sets the breakpoints and watch expressions of the last
+// session and opens its panes. The breakpoints are skipped if they were
+// set with RestoreBreakpoints already.
+func (v *View) RestoreSession() {
+	if v.sessionPath == "" {
+		return
+	}
+	s, err := session.Load(v.sessionPath)
+	if err != nil {
+		v.status.SetError(err)
+		return
+	}
+	v.session = s
+
+	if !v.breakpointsRestored {
+		err = restoreBreakpoints(v.dbg, s.Breakpoints)
+	}
+	v.source.InitBreakpoints(v.dbg)
+
+	v.variables.Watches = slices.Clone(s.Watches)
+	if v.variables.Expanded == nil {
+		v.variables.Expanded = map[string]bool{}
+	}
+	for _, path := range s.Expanded {
+		v.variables.Expanded[path] = true
+	}
+
+	v.outputOpen = s.Layout.Output
+	v.logsOpen = s.Layout.Logs
+	v.goroutinesOpen = s.Layout.Goroutines
+	v.stackOpen = s.Layout.Stack
+	v.registersOpen = s.Layout.Registers
+	v.breakpointsOpen = s.Layout.Breakpoints
+	v.disasmOpen = s.Layout.Disassembly
+	v.Resize(v.width, v.height)
+
+	if err != nil {
+		v.status.SetError(err)
+	}
+}
+
+func restoreBreakpoints(dbg dlv.Backend, bps []session.Breakpoint) error {
+	// Breakpoints cannot be set in core dumps. They are kept in the
+	// session for the next live run.
+	if dbg.ReadOnly() {
+		return nil
+	}
+	var failed []string
+	for _, bp := range bps {
+		if err := restoreBreakpoint(dbg, bp); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", sessionLocation(bp), err))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New("breakpoints of the last session not set: " + strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// restoreBreakpoint sets bp unless the same breakpoint exists, like the
+// one on main.main, and applies its condition and state.
+func restoreBreakpoint(dbg dlv.Backend, bp session.Breakpoint) error {
+	var created *api.Breakpoint
+	if bp.File != "" {
+		created = breakpointAt(dbg.Breakpoints(), bp.File, bp.Line)
+	} else {
+		for _, b := range dbg.Breakpoints() {
+			if name, ok := dbg.BreakpointFunction(b.ID); ok && name == bp.Function {
+				created = b
+			}
+		}
+	}
+	if created == nil {
+		var err error
+		switch {
+		case bp.File == "":
+			created, err = dbg.CreateFunctionBreakpoint(bp.Function)
+		case bp.Log != "":
+			created, err = dbg.CreateLogpoint(bp.File, bp.Line, bp.Log)
+		default:
+			created, err = dbg.CreateFileBreakpoint(bp.File, bp.Line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if bp.Cond != "" || bp.HitCond != "" {
+		if err := dbg.SetBreakpointCondition(created.ID, bp.Cond, bp.HitCond); err != nil {
+			return err
+		}
+	}
+	if bp.Disabled {
+		return dbg.SetBreakpointEnabled(created.ID, false)
+	}
+	return nil
+}
+
+func sessionLocation(bp session.Breakpoint) string {
+	if bp.File == "" {
+		return bp.Function
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(bp.File), bp.Line)
+}
+
+// SaveSession writes the breakpoints, watch expressions, expanded
+// variables and open panes to the session file. It only saves once, as
+// the breakpoints are gone after detaching.
+func (v *View) SaveSession() error {
+	if v.sessionPath == "" {
+		return nil
+	}
+	path := v.sessionPath
+	v.sessionPath = ""
+
+	s := &session.Session{
+		Watches: v.variables.Watches,
+		Layout: session.Layout{
+			Output:      v.outputOpen,
+			Logs:        v.logsOpen,
+			Goroutines:  v.goroutinesOpen,
+			Stack:       v.stackOpen,
+			Registers:   v.registersOpen,
+			Breakpoints: v.breakpointsOpen,
+			Disassembly: v.disasmOpen && !v.disasmAuto,
+		},
+	}
+	for p, expanded := range v.variables.Expanded {
+		if expanded {
+			s.Expanded = append(s.Expanded, p)
+		}
+	}
+	slices.Sort(s.Expanded)
+
+	if v.dbg.ReadOnly() {
+		if v.session != nil {
+			s.Breakpoints = v.session.Breakpoints
+		}
+		return s.Save(path)
+	}
+	for _, bp := range v.dbg.Breakpoints() {
+		// Watchpoints belong to the memory of a process.
+		if bp.ID <= 0 || bp.WatchExpr != "" {
+			continue
+		}
+		sb := session.Breakpoint{
+			Cond:     bp.Cond,
+			HitCond:  bp.HitCond,
+			Disabled: bp.Disabled,
+		}
+		// Breakpoints on functions are kept on them, as their lines
+		// move when the file is edited.
+		if name, ok := v.dbg.BreakpointFunction(bp.ID); ok {
+			sb.Function = name
+		} else if bp.File == "" || bp.File == "<multiple locations>" {
+			sb.Function = bp.FunctionName
+		} else {
+			sb.File, sb.Line = bp.File, bp.Line
+		}
+		if bp.Tracepoint {
+			sb.Log, _ = v.dbg.LogpointFormat(bp.ID)
+		}
+		s.Breakpoints = append(s.Breakpoints, sb)
+	}
+	return s.Save(path)
+}
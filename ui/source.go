@@ -32,6 +32,9 @@ type Source struct {
 	File        File
 	Cursors     Cursors
 	Breakpoints []*api.Breakpoint
+	// CallerFrame is set when a frame other than the topmost one is
+	// selected, so the PC marks a return address.
+	CallerFrame bool
 }
 
 func (s *Source) Resize(w, h int) {
@@ -61,21 +64,32 @@ func (s *Source) CenterCursor() {
 	s.File.LineOffset = max(0, min(s.Cursors.Line-s.Size.Height/2, len(s.File.Lines)-s.Size.Height))
 }
 
-func (s *Source) InitBreakpoints(dbg *dlv.Debugger) {
+func (s *Source) InitBreakpoints(dbg dlv.Backend) {
 	s.Breakpoints = dbg.Breakpoints()
 }
 
-func (s *Source) ToggleBreakpoint(dbg *dlv.Debugger) {
-	var activeBP *api.Breakpoint
-	for _, bp := range s.Breakpoints {
-		if bp.File == s.File.Name && bp.Line == s.Cursors.Line+1 {
-			activeBP = bp
-			break
+// BreakpointAtCursor returns the breakpoint on the line under the cursor
+// or nil if there is none.
+func (s *Source) BreakpointAtCursor() *api.Breakpoint {
+	return breakpointAt(s.Breakpoints, s.File.Name, s.Cursors.Line+1)
+}
+
+// breakpointAt returns the breakpoint at file and line or nil if there is
+// none. Watchpoints are not tied to a line and never match.
+func breakpointAt(bps []*api.Breakpoint, file string, line int) *api.Breakpoint {
+	for _, bp := range bps {
+		if bp.File == file && bp.Line == line && bp.WatchExpr == "" {
+			return bp
 		}
 	}
+	return nil
+}
 
+func (s *Source) ToggleBreakpoint(dbg dlv.Backend) {
+	activeBP := s.BreakpointAtCursor()
 	if activeBP == nil {
-		debug.Logf("%v", dbg.CreateFileBreakpoint(s.File.Name, s.Cursors.Line+1))
+		_, err := dbg.CreateFileBreakpoint(s.File.Name, s.Cursors.Line+1)
+		debug.Logf("%v", err)
 	} else {
 		dbg.ClearBreakpoint(activeBP.ID)
 	}
@@ -92,21 +106,40 @@ func (s *Source) LoadLocation(file string, line int) {
 	s.Cursors.Line = line - 1
 
 	if s.File.Name != file {
-		src, err := os.ReadFile(file)
-		must(err)
-
-		src = bytes.ReplaceAll(src, []byte{'\t'}, []byte("    "))
-		if len(src) > 0 && src[len(src)-1] == '\n' {
-			src = src[:len(src)-1]
+		lines, err := readSource(file)
+		if err != nil {
+			// Attached processes and core files may have been built on
+			// another machine, so the sources are not always around.
+			lines = [][]byte{[]byte("(source not available: " + err.Error() + ")")}
 		}
-
-		s.File.Lines = bytes.Split(src, []byte{'\n'})
+		s.File.Lines = lines
 		s.File.Name = file
 	}
 
 	s.CenterCursor()
 }
 
+// Reload reads the shown file again, as it may have been edited.
+func (s *Source) Reload() {
+	if lines, err := readSource(s.File.Name); err == nil {
+		s.File.Lines = lines
+	}
+}
+
+// readSource returns the lines of file with tabs expanded.
+func readSource(file string) ([][]byte, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	src = bytes.ReplaceAll(src, []byte{'\t'}, []byte("    "))
+	if len(src) > 0 && src[len(src)-1] == '\n' {
+		src = src[:len(src)-1]
+	}
+	return bytes.Split(src, []byte{'\n'}), nil
+}
+
 func (s *Source) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
 	if len(s.File.Lines) == 0 {
 		return
@@ -124,14 +157,17 @@ func (s *Source) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
 		y := i - s.File.LineOffset + offsetY
 		x := offsetX
 
-		if i == s.Cursors.Line || i == s.Cursors.PC {
+		if i == s.Cursors.PC && s.CallerFrame {
+			x = text.WriteString(y, x, "-> ")
+		} else if i == s.Cursors.Line || i == s.Cursors.PC {
 			x = text.WriteString(y, x, "=> ")
 		} else {
 			x = text.WriteString(y, x, "   ")
 		}
 
-		if contains(breakpoints, i) {
-			x = text.WriteString(y, x, "* ")
+		if bp, ok := breakpoints[i]; ok {
+			text.WriteAt(y, x, breakpointGlyph(bp))
+			x = text.WriteString(y, x+1, " ")
 		} else {
 			x = text.WriteString(y, x, "  ")
 		}
@@ -152,14 +188,16 @@ func (s *Source) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
 			colors.SetColor(y, x, 3, frame.ColorFGBlack)
 		} else if i == s.Cursors.Line {
 			colors.SetColor(y, x, 3, frame.ColorFGGreen)
+		} else if i == s.Cursors.PC && s.CallerFrame {
+			colors.SetColor(y, x, 3, frame.ColorFGBlue)
 		} else if i == s.Cursors.PC {
 			colors.SetColor(y, x, 3, frame.ColorFGYellow)
 		} else {
 			colors.SetColor(y, x, 3, frame.ColorReset)
 		}
 
-		if contains(breakpoints, i) {
-			colors.SetColor(y, x+3, 1, frame.ColorFGRed)
+		if bp, ok := breakpoints[i]; ok {
+			colors.SetColor(y, x+3, 1, breakpointColor(bp))
 		}
 
 		colors.SetColor(y, x+5, lineNumWidth+1, frame.ColorFGBlue)
@@ -195,21 +233,37 @@ func paddedItoa(buf []byte, n int) {
 
 }
 
-func contains(nn []int, n int) bool {
-	for _, x := range nn {
-		if x == n {
-			return true
+// fileBreakpoints returns the breakpoints in file by line index.
+func fileBreakpoints(bps []*api.Breakpoint, file string) map[int]*api.Breakpoint {
+	lines := map[int]*api.Breakpoint{}
+	for _, bp := range bps {
+		// Watchpoints are not tied to a line.
+		if bp.File == file && bp.WatchExpr == "" {
+			lines[bp.Line-1] = bp
 		}
 	}
-	return false
+	return lines
 }
 
-func fileBreakpoints(bps []*api.Breakpoint, file string) []int {
-	var lineNums []int
-	for _, bp := range bps {
-		if bp.File == file {
-			lineNums = append(lineNums, bp.Line-1)
-		}
+func breakpointGlyph(bp *api.Breakpoint) rune {
+	if bp.Tracepoint {
+		return '◆'
+	}
+	if bp.Cond != "" || bp.HitCond != "" {
+		return '?'
+	}
+	return '*'
+}
+
+func breakpointColor(bp *api.Breakpoint) rune {
+	if bp.Disabled {
+		return frame.ColorFGBlack
+	}
+	if bp.Tracepoint {
+		return frame.ColorFGBlue
+	}
+	if bp.Cond != "" || bp.HitCond != "" {
+		return frame.ColorFGYellow
 	}
-	return lineNums
+	return frame.ColorFGRed
 }
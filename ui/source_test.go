@@ -9,20 +9,14 @@ import (
 	"unicode/utf8"
 
 	"github.com/go-delve/delve/service/api"
-	"github.com/mattn/go-tty"
+	"github.com/philippta/godbg/frame"
 )
 
 //go:embed testdata/format.go
 var testfile []byte
 
 func TestSourceRender(t *testing.T) {
-	tty, err := tty.Open()
-	if err != nil {
-		t.Fatal(err)
-	}
-	w, _, _ := tty.Size()
-	tty.Close()
-
+	const w = 90
 	src := bytes.ReplaceAll(testfile, []byte{'\t'}, []byte("    "))
 	lines := bytes.Split(src, []byte{'\n'})
 
@@ -34,7 +28,9 @@ func TestSourceRender(t *testing.T) {
 		Breakpoints: []*api.Breakpoint{{Line: 61}},
 	}
 
-	text, colors := source.RenderFrame()
+	text, colors := frame.New(50, w), frame.New(50, w)
+	text.FillSpace()
+	source.RenderFrame(text, colors, 0, 0)
 	text.PrintColored(os.Stdout, colors)
 }
 
@@ -58,8 +54,9 @@ func BenchmarkSourceRender(b *testing.B) {
 		Cursors:     Cursors{PC: 60, Line: 64},
 		Breakpoints: []*api.Breakpoint{{Line: 61}},
 	}
+	text, colors := frame.New(50, 90), frame.New(50, 90)
 	for n := 0; n < b.N; n++ {
-		source.RenderFrame()
+		source.RenderFrame(text, colors, 0, 0)
 	}
 }
 
@@ -79,7 +76,8 @@ func BenchmarkSourceRenderToFrame(b *testing.B) {
 		Breakpoints: []*api.Breakpoint{{Line: 61}},
 	}
 
+	text, colors := frame.New(50, 90), frame.New(50, 90)
 	for n := 0; n < b.N; n++ {
-		source.RenderFrame()
+		source.RenderFrame(text, colors, 0, 0)
 	}
 }
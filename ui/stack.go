@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/frame"
+)
+
+type Stack struct {
+	Focused     bool
+	Size        Size
+	Frames      []api.Stackframe
+	Visible     []int
+	Selected    int
+	HideRuntime bool
+	LineCursor  int
+	LineStart   int
+}
+
+func (s *Stack) Resize(w, h int) {
+	s.Size.Width, s.Size.Height = w, h
+	s.AlignCursor()
+}
+
+// Load replaces the frames and moves the cursor to the selected frame.
+func (s *Stack) Load(frames []api.Stackframe, selected int) {
+	s.Frames = frames
+	s.Selected = selected
+	s.filter()
+}
+
+func (s *Stack) filter() {
+	s.Visible = s.Visible[:0]
+	s.LineCursor = 0
+	for i, f := range s.Frames {
+		if s.HideRuntime && isRuntimeFrame(f) && i != s.Selected {
+			continue
+		}
+		if i == s.Selected {
+			s.LineCursor = len(s.Visible)
+		}
+		s.Visible = append(s.Visible, i)
+	}
+	s.AlignCursor()
+}
+
+// ToggleRuntime hides or shows the frames inside the runtime.
+func (s *Stack) ToggleRuntime() {
+	s.HideRuntime = !s.HideRuntime
+	s.filter()
+}
+
+// MoveUp returns the index of the frame above the cursor, towards the
+// top of the stack.
+func (s *Stack) MoveUp() int {
+	s.LineCursor = max(0, s.LineCursor-1)
+	s.AlignCursor()
+	return s.frameAtCursor()
+}
+
+// MoveDown returns the index of the frame below the cursor, towards the
+// callers.
+func (s *Stack) MoveDown() int {
+	s.LineCursor = min(s.LineCursor+1, max(0, len(s.Visible)-1))
+	s.AlignCursor()
+	return s.frameAtCursor()
+}
+
+func (s *Stack) frameAtCursor() int {
+	if s.LineCursor >= len(s.Visible) {
+		return 0
+	}
+	return s.Visible[s.LineCursor]
+}
+
+func (s *Stack) AlignCursor() {
+	height := s.Size.Height - 1
+	s.LineCursor = min(s.LineCursor, max(0, len(s.Visible)-1))
+	if s.LineCursor < s.LineStart {
+		s.LineStart = s.LineCursor
+	}
+	if s.LineCursor > s.LineStart+height-1 {
+		s.LineStart = s.LineCursor - height + 1
+	}
+	s.LineStart = max(0, min(s.LineStart, len(s.Visible)-height))
+}
+
+func isRuntimeFrame(f api.Stackframe) bool {
+	return f.Function == nil || strings.HasPrefix(f.Function.Name(), "runtime.")
+}
+
+func (s *Stack) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	x := renderTitle(text, colors, offsetY, offsetX, s.Size.Width, " Stack ", s.Focused)
+	if s.HideRuntime {
+		label := " runtime hidden "
+		label = label[:min(len(label), max(0, offsetX+s.Size.Width-x-1))]
+		colors.SetColor(offsetY, x+1, len(label), frame.ColorFGBlue)
+		text.WriteString(offsetY, x+1, label)
+	}
+
+	lineEnd := min(s.LineStart+s.Size.Height-1, len(s.Visible))
+	for i := s.LineStart; i < lineEnd; i++ {
+		y := i - s.LineStart + offsetY + 1
+		x := offsetX
+		n := s.Visible[i]
+		f := s.Frames[n]
+
+		if n == s.Selected {
+			x = text.WriteString(y, x, "=> ")
+		} else {
+			x = text.WriteString(y, x, "   ")
+		}
+		if s.Focused {
+			colors.SetColor(y, offsetX, 3, frame.ColorFGGreen)
+		} else {
+			colors.SetColor(y, offsetX, 3, frame.ColorFGBlack)
+		}
+
+		row := fmt.Sprintf("#%-2d %s  %s:%d", n, locationFunction(f.Location), filepath.Base(f.File), f.Line)
+		row = row[:min(len(row), max(0, offsetX+s.Size.Width-x))]
+		switch {
+		case n == s.Selected && s.Focused:
+			colors.SetColor(y, x, len(row), frame.ColorFGWhite)
+		case isRuntimeFrame(f):
+			colors.SetColor(y, x, len(row), frame.ColorFGBlack)
+		}
+		text.WriteString(y, x, row)
+	}
+}
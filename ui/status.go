@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"github.com/philippta/godbg/frame"
+)
+
+type Status struct {
+	Size    Size
+	Labels  []string
+	Message string
+	IsError bool
+}
+
+func (s *Status) Resize(w, h int) {
+	s.Size.Width, s.Size.Height = w, h
+}
+
+func (s *Status) SetMessage(msg string) {
+	s.Message = msg
+	s.IsError = false
+}
+
+func (s *Status) SetError(err error) {
+	s.Message = err.Error()
+	s.IsError = true
+}
+
+func (s *Status) ClearMessage() {
+	s.Message = ""
+	s.IsError = false
+}
+
+func (s *Status) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
+	x := offsetX
+	for _, label := range s.Labels {
+		if x+len(label)+2 > offsetX+s.Size.Width {
+			break
+		}
+		colors.SetColor(offsetY, x, len(label)+2, frame.ColorFGBlue)
+		x = text.WriteString(offsetY, x, " "+label+" ")
+		x = text.WriteString(offsetY, x, " ")
+	}
+
+	if s.Message == "" || x >= offsetX+s.Size.Width {
+		return
+	}
+	msg := s.Message[:min(len(s.Message), offsetX+s.Size.Width-x)]
+	if s.IsError {
+		colors.SetColor(offsetY, x, len(msg), frame.ColorFGRed)
+	} else {
+		colors.SetColor(offsetY, x, len(msg), frame.ColorFGWhite)
+	}
+	text.WriteString(offsetY, x, msg)
+}
@@ -2,44 +2,93 @@ package ui
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
+	"github.com/go-delve/delve/service/api"
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/mattn/go-tty"
+	"github.com/philippta/godbg/build"
 	"github.com/philippta/godbg/debug"
 	"github.com/philippta/godbg/dlv"
 	"github.com/philippta/godbg/frame"
 	"github.com/philippta/godbg/perf"
+	"github.com/philippta/godbg/session"
 	"github.com/philippta/godbg/term"
 )
 
 const (
 	PaneSource = iota
 	PaneVariables
+	PaneOutput
+	PaneLogs
+	PaneGoroutines
+	PaneStack
+	PaneRegisters
+	PaneCrash
+	PaneBreakpoints
 	PaneCount
 )
 
-func Run(dbg *dlv.Debugger, dir string) {
+var (
+	errReadOnly = errors.New("core dump is read-only: stepping and continuing are disabled")
+	errRunning  = errors.New("program is running, press ctrl+c to halt")
+	errExited   = errors.New("program exited, press R to restart")
+)
+
+// Options configures the UI.
+type Options struct {
+	// SessionPath is the file the session is restored from and saved to.
+	// Sessions are disabled if it is empty.
+	SessionPath string
+	// BreakpointsRestored reports that the breakpoints of the session
+	// were set with RestoreBreakpoints before the target started.
+	BreakpointsRestored bool
+	// Err is shown in the status line at the start, for example for
+	// breakpoints of a launch configuration that could not be set.
+	Err error
+}
+
+// Run shows the UI for dbg until it is quit. The session at
+// opts.SessionPath is restored and saved on exit.
+func Run(dbg dlv.Backend, dir string, opts Options) {
 	tty, err := tty.Open()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer tty.Close()
 
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
 	previewCache, err := lru.New[string, []string](100)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	v := &View{
-		dbg:   dbg,
-		tty:   tty,
-		focus: PaneSource,
+		dbg:    dbg,
+		tty:    tty,
+		cancel: cancel,
+		focus:  PaneSource,
+		logs:   Output{Title: "Logs"},
+
+		sessionPath:         opts.SessionPath,
+		breakpointsRestored: opts.BreakpointsRestored,
 		files: Files{
 			Dir:          dir,
 			PreviewCache: previewCache,
@@ -57,9 +106,22 @@ func Run(dbg *dlv.Debugger, dir string) {
 
 	v.files.LoadFiles()
 	v.source.InitBreakpoints(v.dbg)
+	v.RestoreSession()
+	if opts.Err != nil {
+		v.status.SetError(opts.Err)
+	}
 	v.Update()
 	v.Paint()
 
+	// The session is saved after the terminal is restored, so errors
+	// can be printed.
+	defer func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if err := v.SaveSession(); err != nil {
+			fmt.Fprintf(os.Stderr, "save session: %v\n", err)
+		}
+	}()
 	defer v.Close()
 
 	go func() {
@@ -67,11 +129,26 @@ func Run(dbg *dlv.Debugger, dir string) {
 		cancel()
 	}()
 
-	<-ctx.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigs:
+			// The terminal turns ctrl+c into SIGINT, which halts a running
+			// program and quits otherwise.
+			if sig == syscall.SIGINT && v.Halt() {
+				continue
+			}
+			v.haltForExit()
+			return
+		}
+	}
 }
 
 type View struct {
-	tty *tty.TTY
+	mu     sync.Mutex
+	tty    *tty.TTY
+	cancel func()
 
 	width    int
 	height   int
@@ -80,10 +157,78 @@ type View struct {
 
 	source    Source
 	variables Variables
+
+	// The disassembly is shown in place of the source. disasmAuto is set
+	// when it was opened because there is no source for the location.
+	disasm     Disassembly
+	disasmOpen bool
+	disasmAuto bool
+
 	files     Files
 	filesOpen bool
 
-	dbg *dlv.Debugger
+	funcs     Functions
+	funcsOpen bool
+
+	output     Output
+	outputOpen bool
+
+	logs     Output
+	logsOpen bool
+
+	goroutines     Goroutines
+	goroutinesOpen bool
+
+	stack     Stack
+	stackOpen bool
+
+	registers     Registers
+	registersOpen bool
+
+	crash     Crash
+	crashOpen bool
+
+	breakpoints     Breakpoints
+	breakpointsOpen bool
+	// stops counts the stops of the program, so the registers pane can
+	// tell which values changed since the previous one.
+	stops int
+
+	quitOpen bool
+	status   Status
+
+	// exitOpen shows the exit status and the last output of the program
+	// once it exited.
+	exitOpen bool
+	// buildErrors holds the compiler output of a failed rebuild while it
+	// is shown.
+	buildErrors []string
+
+	inputMode bool
+	input     Prompt
+
+	promptOpen bool
+	prompt     Prompt
+	promptDone func(string)
+
+	running       bool
+	quitAfterHalt bool
+	// halted is set once the running command is halted, so commands
+	// repeated by a count stop as well.
+	halted atomic.Bool
+
+	// sessionPath is the file the session is saved to, or empty if it
+	// is not saved. session is the one restored on startup. Its
+	// breakpoints are only set if breakpointsRestored is false.
+	sessionPath         string
+	session             *session.Session
+	breakpointsRestored bool
+
+	// count is the number typed before a step or continue key, which
+	// repeats the command.
+	count int
+
+	dbg dlv.Backend
 }
 
 func (v *View) InputLoop() {
@@ -100,102 +245,575 @@ func (v *View) InputLoop() {
 		if err != nil {
 			panic(err)
 		}
+		v.mu.Lock()
+		v.status.ClearMessage()
+		quit := v.HandleKey(key)
+		for v.tty.Buffered() {
+			key, _ := v.tty.ReadRune()
+			debug.Logf("  Buffered: %v", key)
+		}
+		if !quit {
+			v.Paint()
+		}
+		v.mu.Unlock()
 
-		if !v.filesOpen {
-			switch v.focus {
-			case PaneSource:
-				switch key {
-				case '\t':
-					v.focus = (v.focus + 1) % PaneCount
-					v.UpdateFocus()
-				case 'k': // Move up
+		if quit {
+			return
+		}
+	}
+}
+
+// HandleKey handles a single key press and reports whether the UI should
+// exit.
+func (v *View) HandleKey(key rune) bool {
+	count := v.count
+	v.count = 0
+
+	if v.inputMode {
+		v.HandleProgramInput(key, v.readMore())
+	} else if v.promptOpen {
+		v.HandlePrompt(key, v.readMore())
+	} else if v.quitOpen {
+		switch key {
+		case 'd': // Detach
+			v.saveBeforeDetach()
+			v.dbg.Detach(false)
+			return true
+		case 'k': // Kill
+			v.saveBeforeDetach()
+			v.dbg.Detach(true)
+			return true
+		case 27, 'q': // ESC
+			v.quitOpen = false
+		}
+	} else if v.buildErrors != nil {
+		switch key {
+		case 27, 'q': // ESC
+			v.buildErrors = nil
+		}
+	} else if v.exitOpen {
+		switch key {
+		case 'r', 'R': // Restart
+			v.Restart()
+		case 'q': // Quit
+			return true
+		case 27: // ESC
+			v.exitOpen = false
+		}
+	} else if v.funcsOpen {
+		v.HandleFunctionsKey(key, v.readMore())
+	} else if !v.filesOpen {
+		switch v.focus {
+		case PaneSource:
+			if key >= '1' && key <= '9' || key == '0' && count > 0 {
+				v.count = min(count*10+int(key-'0'), maxCount)
+				v.status.SetMessage(strconv.Itoa(v.count))
+				break
+			}
+			switch key {
+			case 'k': // Move up
+				if v.disasmOpen {
+					v.disasm.MoveUp()
+				} else {
 					v.source.MoveUp()
-				case 'j': // Move down
+				}
+			case 'j': // Move down
+				if v.disasmOpen {
+					v.disasm.MoveDown()
+				} else {
 					v.source.MoveDown()
-				case 's': // Step
-					v.dbg.Step()
-					v.Update()
-				case 'i': // Step in
-					v.dbg.StepIn()
-					v.Update()
-				case 'o': // Step out
-					v.dbg.StepOut()
-					v.Update()
-				case 'c': // Continue
-					v.dbg.Continue()
-					v.Update()
-				case 'b': // Breakpoint
+				}
+			case 's': // Step
+				v.Exec(v.repeat(v.dbg.Step, count))
+			case 'i': // Step in
+				v.Exec(v.repeat(v.dbg.StepIn, count))
+			case 'o': // Step out
+				v.Exec(v.dbg.StepOut)
+			case 'c': // Continue
+				v.Exec(v.repeat(v.dbg.Continue, count))
+			case 'C': // Run to cursor
+				v.RunToCursor()
+			case 'R': // Restart
+				v.Restart()
+			case 'I': // Step instruction
+				v.Exec(v.dbg.StepInstruction)
+			case 'N': // Next instruction
+				v.Exec(v.dbg.NextInstruction)
+			case 'a': // Disassembly
+				v.ToggleDisassembly()
+			case '[': // Previous goroutine
+				v.SwitchGoroutine(-1)
+			case ']': // Next goroutine
+				v.SwitchGoroutine(1)
+			case 'b': // Breakpoint
+				if v.disasmOpen {
+					v.ToggleInstructionBreakpoint()
+				} else if v.Stopped() {
 					v.source.ToggleBreakpoint(v.dbg)
-				case 'q':
-					return
-				case 16:
-					v.filesOpen = true
-					v.files.Reset()
 				}
-			case PaneVariables:
-				switch key {
-				case '\t':
-					v.focus = (v.focus + 1) % PaneCount
-					v.UpdateFocus()
-				case 'k': // Move up
-					v.variables.MoveUp()
-				case 'j': // Move down
-					v.variables.MoveDown()
-				case 'l': // Expand
-					v.variables.Expand()
-				case 'h': // Collapse
-					v.variables.Collapse()
-				case 'q':
-					return
-				case 16:
-					v.filesOpen = true
-					v.files.Reset()
+			case '?': // Breakpoint condition and hit condition
+				v.EditBreakpointCondition()
+			case 'L': // Logpoint
+				v.EditLogpoint()
+			case 'B': // Function breakpoint
+				v.OpenFunctions()
+			case 'e': // Evaluate
+				v.EvalPrompt()
+			case 'u': // Up the stack
+				v.MoveFrame(1)
+			case 'd': // Down the stack
+				v.MoveFrame(-1)
+			default:
+				return v.HandleCommonKey(key)
+			}
+		case PaneVariables:
+			if v.variables.Editing {
+				v.HandleVariableEdit(key, v.readMore())
+				break
+			}
+			switch key {
+			case 'k': // Move up
+				v.variables.MoveUp()
+			case 'j': // Move down
+				v.variables.MoveDown()
+			case 'l': // Expand
+				v.variables.Expand()
+			case 'h': // Collapse
+				v.variables.Collapse()
+			case 'w': // Watchpoint
+				v.WatchVariable()
+			case 'e': // Evaluate
+				v.EvalPrompt()
+			case 13: // Enter
+				v.EditVariable()
+			case 'a': // Add watch expression
+				v.AddWatch()
+			case 'x': // Remove watch expression
+				if v.Stopped() && v.variables.RemoveWatch() {
+					v.Update()
 				}
+			case '[': // Previous goroutine
+				v.SwitchGoroutine(-1)
+			case ']': // Next goroutine
+				v.SwitchGoroutine(1)
+			default:
+				return v.HandleCommonKey(key)
+			}
+		case PaneOutput, PaneLogs:
+			list := &v.output
+			if v.focus == PaneLogs {
+				list = &v.logs
+			}
+			if list.Searching {
+				list.HandleSearchInput(key, v.readMore())
+				break
 			}
-		} else {
 			switch key {
-			case 16: // CTRL+P
-				v.filesOpen = false
+			case 'k': // Move up
+				list.MoveUp()
+			case 'j': // Move down
+				list.MoveDown()
+			case 'g': // Top
+				list.MoveTop()
+			case 'G': // Bottom
+				list.MoveBottom()
+			case '/': // Search
+				list.StartSearch()
+			case 'n': // Next match
+				list.NextMatch(1)
+			case 'N': // Previous match
+				list.NextMatch(-1)
+			case 'x': // Clear
+				if v.focus == PaneLogs {
+					v.dbg.Logs().Clear()
+				} else {
+					v.dbg.Output().Clear()
+				}
+				list.Load(nil)
 			default:
-				var more []rune
-				for v.tty.Buffered() {
-					key, _ := v.tty.ReadRune()
-					more = append(more, key)
+				return v.HandleCommonKey(key)
+			}
+		case PaneGoroutines:
+			if v.goroutines.Filtering {
+				v.goroutines.HandleFilterInput(key, v.readMore())
+				break
+			}
+			switch key {
+			case 'k': // Move up
+				v.goroutines.MoveUp()
+			case 'j': // Move down
+				v.goroutines.MoveDown()
+			case '/': // Filter
+				v.goroutines.StartFilter()
+			case 13: // Enter
+				if g, ok := v.goroutines.Selected(); ok {
+					v.SelectGoroutine(g.ID)
 				}
-				if key == 27 && len(more) == 0 { // ESC
-					v.filesOpen = false
-					v.files.Reset()
-					break
+			default:
+				return v.HandleCommonKey(key)
+			}
+		case PaneStack:
+			switch key {
+			case 'k': // Move up
+				v.SelectFrame(v.stack.MoveUp())
+			case 'j': // Move down
+				v.SelectFrame(v.stack.MoveDown())
+			case 'r': // Runtime frames
+				v.stack.ToggleRuntime()
+			default:
+				return v.HandleCommonKey(key)
+			}
+		case PaneCrash:
+			switch key {
+			case 'k': // Move up
+				v.crash.Value.MoveUp()
+			case 'j': // Move down
+				v.crash.Value.MoveDown()
+			case 'l': // Expand
+				v.crash.Value.Expand()
+			case 'h': // Collapse
+				v.crash.Value.Collapse()
+			default:
+				return v.HandleCommonKey(key)
+			}
+		case PaneBreakpoints:
+			switch key {
+			case 'k': // Move up
+				v.breakpoints.MoveUp()
+			case 'j': // Move down
+				v.breakpoints.MoveDown()
+			case ' ': // Enable or disable
+				v.ToggleBreakpointEnabled()
+			case 'x': // Delete
+				if bp, ok := v.breakpoints.Selected(); ok {
+					v.DeleteBreakpoints(bp)
 				}
-				if key == 13 { // Enter
-					requestedFile := v.files.FilteredFiles[v.files.FileCursor]
-					v.filesOpen = false
-					v.files.Reset()
-
-					debugFile, debugLine := v.dbg.Location()
-					if debugFile == requestedFile {
-						v.source.LoadLocation(debugFile, debugLine)
-					} else {
-						v.source.LoadLocation(requestedFile, 1)
-						v.source.Cursors.PC = -1
-					}
-					break
+			case 'X': // Delete all
+				v.DeleteBreakpoints(v.breakpoints.Breakpoints...)
+			case 13: // Enter
+				v.JumpToBreakpoint()
+			default:
+				return v.HandleCommonKey(key)
+			}
+		case PaneRegisters:
+			switch key {
+			case 'k': // Move up
+				v.registers.MoveUp()
+			case 'j': // Move down
+				v.registers.MoveDown()
+			case 'f': // Floating point registers
+				v.registers.FloatingPoint = !v.registers.FloatingPoint
+				if v.Stopped() {
+					v.LoadRegisters()
 				}
-				v.files.HandleInput(key, more)
+			default:
+				return v.HandleCommonKey(key)
 			}
 		}
+	} else {
+		switch key {
+		case 16: // CTRL+P
+			v.filesOpen = false
+		default:
+			more := v.readMore()
+			if key == 27 && len(more) == 0 { // ESC
+				v.filesOpen = false
+				v.files.Reset()
+				break
+			}
+			if key == 13 { // Enter
+				requestedFile := v.files.FilteredFiles[v.files.FileCursor]
+				v.filesOpen = false
+				v.files.Reset()
 
-		for v.tty.Buffered() {
-			key, _ := v.tty.ReadRune()
-			debug.Logf("  Buffered: %v", key)
+				var debugFile string
+				var debugLine int
+				if !v.running {
+					debugFile, debugLine = v.dbg.Location()
+				}
+				if debugFile == requestedFile {
+					v.source.LoadLocation(debugFile, debugLine)
+				} else {
+					v.source.LoadLocation(requestedFile, 1)
+					v.source.Cursors.PC = -1
+				}
+				break
+			}
+			v.files.HandleInput(key, more)
 		}
+	}
+	return false
+}
 
-		if v.dbg.Exited() {
+// HandleCommonKey handles the keys that work the same in all panes and
+// reports whether the UI should exit.
+func (v *View) HandleCommonKey(key rune) bool {
+	switch key {
+	case '\t':
+		v.NextFocus()
+	case 'q':
+		return v.Quit()
+	case 7: // CTRL+G
+		v.ToggleGoroutines()
+	case 11: // CTRL+K
+		v.ToggleStack()
+	case 18: // CTRL+R
+		v.ToggleRegisters()
+	case 2: // CTRL+B
+		v.ToggleBreakpoints()
+	case 15: // CTRL+O
+		v.ToggleOutput()
+	case 20: // CTRL+T
+		v.StartProgramInput()
+	case 12: // CTRL+L
+		v.ToggleLogs()
+	case 16: // CTRL+P
+		v.filesOpen = true
+		v.files.Reset()
+	}
+	return false
+}
+
+// Ask opens a prompt in the status line and calls done with the entered
+// text once it is submitted.
+func (v *View) Ask(label, input string, done func(string)) {
+	v.promptOpen = true
+	v.promptDone = done
+	v.prompt.Reset(label, input)
+}
+
+func (v *View) HandlePrompt(key rune, more []rune) {
+	switch v.prompt.HandleInput(key, more) {
+	case PromptSubmit:
+		done := v.promptDone
+		v.promptOpen = false
+		v.promptDone = nil
+		done(v.prompt.Text())
+	case PromptCancel:
+		v.promptOpen = false
+		v.promptDone = nil
+	}
+}
+
+// maxFunctionBreakpoints limits the number of functions a regular
+// expression can set breakpoints on.
+const maxFunctionBreakpoints = 100
+
+// OpenFunctions opens the picker for setting breakpoints on functions.
+func (v *View) OpenFunctions() {
+	if !v.Stopped() {
+		return
+	}
+	names, err := v.dbg.Functions()
+	if err != nil {
+		v.status.SetError(err)
+		return
+	}
+	if v.funcs.Module == "" {
+		v.funcs.Module, _ = build.ModulePath(v.files.Dir)
+	}
+	v.funcs.Load(names)
+	v.funcsOpen = true
+}
+
+func (v *View) HandleFunctionsKey(key rune, more []rune) {
+	switch {
+	case key == 27 && len(more) == 0: // ESC
+		v.funcsOpen = false
+	case key == 13: // Enter
+		v.funcsOpen = false
+		v.BreakOnFunctions()
+	default:
+		v.funcs.HandleInput(key, more)
+	}
+}
+
+// BreakOnFunctions sets a breakpoint on the function selected in the
+// picker, or on all functions matching its regular expression.
+func (v *View) BreakOnFunctions() {
+	names := v.funcs.Matches()
+	if !v.funcs.IsRegexp() {
+		name, ok := v.funcs.Selected()
+		if !ok {
 			return
 		}
+		names = []string{name}
+	}
+	if len(names) == 0 {
+		v.status.SetError(fmt.Errorf("no functions match %s", v.funcs.Search))
+		return
+	}
+	if len(names) > maxFunctionBreakpoints {
+		v.status.SetError(fmt.Errorf("%s matches %d functions, at most %d can have breakpoints", v.funcs.Search, len(names), maxFunctionBreakpoints))
+		return
+	}
 
-		v.Paint()
+	var failed []string
+	for _, name := range names {
+		if _, err := v.dbg.CreateFunctionBreakpoint(name); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	v.source.InitBreakpoints(v.dbg)
+
+	switch {
+	case len(failed) > 0:
+		v.status.SetError(errors.New(strings.Join(failed, "; ")))
+	case len(names) == 1:
+		v.status.SetMessage("breakpoint set on " + names[0])
+	default:
+		v.status.SetMessage(fmt.Sprintf("breakpoints set on %d functions", len(names)))
+	}
+}
+
+// EditBreakpointCondition asks for the condition and hit condition of the
+// breakpoint under the cursor, creating the breakpoint if necessary.
+func (v *View) EditBreakpointCondition() {
+	if !v.Stopped() {
+		return
+	}
+
+	bp := v.source.BreakpointAtCursor()
+	if bp == nil {
+		v.source.ToggleBreakpoint(v.dbg)
+		if bp = v.source.BreakpointAtCursor(); bp == nil {
+			v.status.SetMessage("no breakpoint can be set on this line")
+			return
+		}
 	}
+
+	id, hitCond := bp.ID, bp.HitCond
+	v.Ask("condition: ", bp.Cond, func(cond string) {
+		v.Ask("hit condition (e.g. == 5, > 10, % 2): ", hitCond, func(hitCond string) {
+			if err := v.dbg.SetBreakpointCondition(id, cond, hitCond); err != nil {
+				v.status.SetError(err)
+			}
+			v.source.Breakpoints = v.dbg.Breakpoints()
+		})
+	})
+}
+
+// EditLogpoint asks for the message of the logpoint under the cursor,
+// replacing any breakpoint on the line. Expressions in braces like
+// {user.Name} are evaluated each time the logpoint is hit.
+func (v *View) EditLogpoint() {
+	if !v.Stopped() {
+		return
+	}
+
+	file, line := v.source.File.Name, v.source.Cursors.Line+1
+	var format string
+	bp := v.source.BreakpointAtCursor()
+	if bp != nil {
+		format, _ = v.dbg.LogpointFormat(bp.ID)
+	}
+
+	v.Ask("log message: ", format, func(format string) {
+		if format == "" {
+			return
+		}
+		if bp != nil {
+			if err := v.dbg.ClearBreakpoint(bp.ID); err != nil {
+				v.status.SetError(err)
+				return
+			}
+		}
+		if _, err := v.dbg.CreateLogpoint(file, line, format); err != nil {
+			v.status.SetError(err)
+		}
+		v.source.Breakpoints = v.dbg.Breakpoints()
+	})
+}
+
+// WatchVariable sets a watchpoint on the variable under the cursor and
+// asks whether reads, writes or both should stop the program.
+func (v *View) WatchVariable() {
+	if v.dbg.ReadOnly() {
+		v.status.SetError(errReadOnly)
+		return
+	}
+	if !v.Stopped() {
+		return
+	}
+	va, ok := v.variables.Selected()
+	if !ok {
+		return
+	}
+	if va.Returned {
+		v.status.SetError(fmt.Errorf("%s cannot be watched: returned values are copies", va.Name))
+		return
+	}
+
+	expr := variableExpr(va.Path)
+	v.Ask("watch "+expr+" for (r)ead, (w)rite or (rw): ", "w", func(typ string) {
+		wtype, err := dlv.ParseWatchType(typ)
+		if err != nil {
+			v.status.SetError(err)
+			return
+		}
+		if err := v.dbg.CreateWatchpoint(expr, wtype); err != nil {
+			v.status.SetError(fmt.Errorf("watch %s: %w", expr, err))
+			return
+		}
+		v.status.SetMessage("watching " + expr)
+	})
+}
+
+const inputLabel = " PROGRAM INPUT  ctrl+t to leave, ctrl+d to send EOF "
+
+// StartProgramInput switches to the mode where key presses are sent to
+// the stdin of the target instead of being handled by the UI.
+func (v *View) StartProgramInput() {
+	if !v.dbg.Input().Available() {
+		v.status.SetMessage("program input is not available")
+		return
+	}
+	v.inputMode = true
+	v.input.Reset("> ", "")
+	if !v.outputOpen {
+		v.ToggleOutput()
+	}
+}
+
+func (v *View) HandleProgramInput(key rune, more []rune) {
+	switch key {
+	case 20: // CTRL+T
+		v.inputMode = false
+		return
+	case 4: // CTRL+D
+		v.inputMode = false
+		if err := v.dbg.Input().CloseWrite(); err != nil {
+			v.status.SetError(err)
+			return
+		}
+		v.status.SetMessage("sent EOF to program")
+		return
+	}
+
+	switch v.input.HandleInput(key, more) {
+	case PromptSubmit:
+		line := v.input.Text() + "\n"
+		if _, err := v.dbg.Input().Write([]byte(line)); err != nil {
+			v.inputMode = false
+			v.status.SetError(err)
+			return
+		}
+		v.dbg.Output().Write(dlv.Stdin, []byte(line))
+		v.input.Reset("> ", "")
+	case PromptCancel:
+		v.inputMode = false
+	}
+}
+
+// readMore reads the runes following a key press that are already
+// buffered, such as the remainder of an escape sequence.
+func (v *View) readMore() []rune {
+	var more []rune
+	for v.tty.Buffered() {
+		key, _ := v.tty.ReadRune()
+		more = append(more, key)
+	}
+	return more
 }
 
 func (v *View) Update() {
@@ -205,19 +823,494 @@ func (v *View) Update() {
 	vars, _ := v.dbg.Variables()
 	p.Mark("Variables")
 
-	v.source.LoadLocation(v.dbg.Location())
+	file, line := v.dbg.Location()
+	v.source.LoadLocation(file, line)
+	// Hit counts change with every stop.
+	v.source.InitBreakpoints(v.dbg)
+	v.source.CallerFrame = v.dbg.Frame() != 0
 	p.Mark("LoadLoc")
 
-	v.variables.Load(vars)
+	switch {
+	case file == "" && !v.disasmOpen:
+		v.disasmOpen, v.disasmAuto = true, true
+		v.UpdateFocus()
+	case file != "" && v.disasmAuto:
+		v.disasmOpen, v.disasmAuto = false, false
+		v.UpdateFocus()
+	}
+	if v.disasmOpen {
+		v.LoadDisassembly()
+		p.Mark("LoadDisasm")
+	}
+
+	v.variables.Load(v.EvalWatches(), vars)
 	p.Mark("LoadVar")
 	if v.source.File.Name != v.prevFile {
 		v.variables.ResetCursor(v.height)
 	}
 
 	v.prevFile = v.source.File.Name
+	if v.goroutinesOpen {
+		v.LoadGoroutines()
+	}
+	if v.stackOpen {
+		v.LoadStack()
+	}
+	if v.registersOpen {
+		v.LoadRegisters()
+	}
+	v.UpdateStatus()
 	p.End()
 }
 
+// EvalWatches evaluates the watch expressions in the selected goroutine
+// and frame.
+func (v *View) EvalWatches() []api.Variable {
+	watches := make([]api.Variable, len(v.variables.Watches))
+	for i, expr := range v.variables.Watches {
+		w, err := v.dbg.Eval(expr)
+		if err != nil {
+			watches[i] = api.Variable{Name: expr, Unreadable: err.Error()}
+			continue
+		}
+		watches[i] = *w
+		watches[i].Name = expr
+	}
+	return watches
+}
+
+// EvalPrompt asks for an expression and shows its value in the status
+// line. Expressions starting with "call ", like in Delve, are function
+// calls that are injected into the program.
+func (v *View) EvalPrompt() {
+	if !v.Stopped() {
+		return
+	}
+	v.Ask("eval: ", "", func(expr string) {
+		if call, ok := strings.CutPrefix(expr, "call "); ok {
+			v.Call(strings.TrimSpace(call))
+			return
+		}
+		if expr == "" {
+			return
+		}
+		val, err := v.dbg.Eval(expr)
+		if err != nil {
+			v.status.SetError(err)
+			return
+		}
+		vars := fillValues([]api.Variable{*val})
+		v.status.SetMessage(expr + " = " + vars[0].Value)
+	})
+}
+
+// Call injects the function call expr into the selected goroutine and
+// shows the returned values at the top of the Variables pane. The call
+// runs like continue, so it can be halted and may stop at a breakpoint.
+func (v *View) Call(expr string) {
+	v.Exec(func() error {
+		vals, err := v.dbg.Call(expr)
+		if err != nil {
+			return err
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.variables.SetReturned(expr, vals)
+		vars := v.variables.Returned[0].Children
+		switch len(vars) {
+		case 0:
+			v.status.SetMessage("called " + expr)
+		case 1:
+			v.status.SetMessage(expr + " = " + vars[0].Value)
+		default:
+			values := make([]string, len(vars))
+			for i, va := range vars {
+				values[i] = va.Value
+			}
+			v.status.SetMessage(expr + " = " + strings.Join(values, ", "))
+		}
+		return nil
+	})
+}
+
+// EditVariable opens the inline editor for the value of the variable
+// under the cursor.
+func (v *View) EditVariable() {
+	if v.dbg.ReadOnly() {
+		v.status.SetError(errReadOnly)
+		return
+	}
+	if !v.Stopped() {
+		return
+	}
+	if err := v.variables.StartEdit(); err != nil {
+		v.status.SetError(err)
+	}
+}
+
+// HandleVariableEdit assigns the edited value once it is submitted. If
+// Delve rejects the value, the editor stays open.
+func (v *View) HandleVariableEdit(key rune, more []rune) {
+	switch v.variables.Editor.HandleInput(key, more) {
+	case PromptSubmit:
+		va, ok := v.variables.Selected()
+		if !ok {
+			v.variables.Editing = false
+			return
+		}
+		expr := variableExpr(va.Path)
+		if err := v.dbg.SetVariable(expr, v.variables.Editor.Text()); err != nil {
+			v.status.SetError(fmt.Errorf("set %s: %w", expr, err))
+			return
+		}
+		v.variables.Editing = false
+		v.Update()
+	case PromptCancel:
+		v.variables.Editing = false
+	}
+}
+
+// AddWatch asks for an expression that is evaluated on every stop and
+// shown above the variables.
+func (v *View) AddWatch() {
+	if !v.Stopped() {
+		return
+	}
+	v.Ask("watch expression: ", "", func(expr string) {
+		if expr == "" {
+			return
+		}
+		v.variables.AddWatch(expr)
+		v.Update()
+	})
+}
+
+func (v *View) UpdateStatus() {
+	v.status.Labels = v.status.Labels[:0]
+	if v.dbg.ReadOnly() {
+		v.status.Labels = append(v.status.Labels, "core dump")
+	}
+	if v.dbg.Attached() {
+		v.status.Labels = append(v.status.Labels, "attached")
+	}
+	if v.running {
+		v.status.Labels = append(v.status.Labels, "running...")
+		return
+	}
+	if v.dbg.Exited() {
+		v.status.Labels = append(v.status.Labels, fmt.Sprintf("exited with status %d", v.dbg.ExitStatus()))
+		return
+	}
+	if id := v.dbg.GoroutineID(); id > 0 {
+		v.status.Labels = append(v.status.Labels, fmt.Sprintf("goroutine %d", id))
+	}
+}
+
+// Exec runs an execution command like step or continue in the
+// background and reloads the panes once the program stopped.
+func (v *View) Exec(cmd func() error) {
+	if v.dbg.ReadOnly() {
+		v.status.SetError(errReadOnly)
+		return
+	}
+	if !v.Stopped() {
+		return
+	}
+	v.run(cmd)
+}
+
+// maxCount limits the count prefix of commands.
+const maxCount = 9999
+
+// repeat returns a command running cmd n times, or once if n is 0. It
+// stops early when the program exits or crashes. The panes are only
+// reloaded once it is done.
+func (v *View) repeat(cmd func() error, n int) func() error {
+	return func() error {
+		for i := 0; i < max(1, n); i++ {
+			if err := cmd(); err != nil {
+				return err
+			}
+			if v.halted.Load() || v.dbg.Exited() || v.dbg.Crash() != nil {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+// RunToCursor continues to the line under the cursor, or to the line of
+// the selected instruction in the disassembly.
+func (v *View) RunToCursor() {
+	file, line := v.source.File.Name, v.source.Cursors.Line+1
+	if v.disasmOpen {
+		inst, ok := v.disasm.Selected()
+		if !ok {
+			return
+		}
+		file, line = inst.Loc.File, inst.Loc.Line
+	}
+	if file == "" {
+		return
+	}
+	v.Exec(func() error {
+		return v.dbg.RunTo(file, line)
+	})
+}
+
+// run runs cmd in the background like Exec, without checking whether the
+// program can be run.
+func (v *View) run(cmd func() error) {
+	v.running = true
+	v.halted.Store(false)
+	v.variables.Returned = nil
+	v.UpdateStatus()
+
+	done := make(chan struct{})
+	go v.PaintLoop(done)
+	go func() {
+		err := cmd()
+		close(done)
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		v.running = false
+		v.stops++
+		if v.dbg.Exited() {
+			if v.quitAfterHalt {
+				v.cancel()
+				return
+			}
+			// The panes keep showing the last stop, as there is nothing
+			// left to inspect.
+			v.exitOpen = true
+			v.UpdateStatus()
+			if err != nil {
+				v.status.SetError(err)
+			}
+			v.Paint()
+			return
+		}
+		if vals := v.dbg.ReturnValues(); len(vals) > 0 {
+			v.variables.SetReturned("returned", vals)
+		}
+		if crash := v.dbg.Crash(); crash != nil {
+			v.ShowCrash(crash)
+		} else if v.crashOpen {
+			v.crashOpen = false
+			v.UpdateFocus()
+			v.Resize(v.width, v.height)
+		}
+		if err != nil {
+			v.status.SetError(err)
+		} else if v.crashOpen {
+			v.status.SetError(errors.New(v.crash.Message))
+		} else if events := v.dbg.WatchEvents(); len(events) > 0 {
+			msgs := make([]string, len(events))
+			for i, e := range events {
+				msgs[i] = e.String()
+			}
+			v.status.SetMessage(strings.Join(msgs, "; "))
+		}
+		v.Update()
+		if v.quitAfterHalt {
+			v.quitAfterHalt = false
+			if v.Quit() {
+				v.cancel()
+				return
+			}
+		}
+		v.Paint()
+	}()
+}
+
+// PaintLoop repaints the screen periodically until done is closed, so
+// output of a running program shows up.
+func (v *View) PaintLoop(done <-chan struct{}) {
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			v.mu.Lock()
+			if v.running {
+				v.Paint()
+			}
+			v.mu.Unlock()
+		}
+	}
+}
+
+// Halt stops the running program and reports whether it was running.
+func (v *View) Halt() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.running {
+		return false
+	}
+	go v.halt()
+	return true
+}
+
+// haltForExit stops the running program before the UI exits on a
+// signal. Delve blocks all other calls while the program runs, which
+// would hang saving the session, so it is skipped if halting fails.
+func (v *View) haltForExit() {
+	v.mu.Lock()
+	running := v.running
+	v.mu.Unlock()
+	if !running {
+		return
+	}
+
+	// Delve returns once the running command finished.
+	v.halted.Store(true)
+	err := v.dbg.Halt()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err != nil {
+		debug.Logf("halt for exit: %v", err)
+		v.sessionPath = ""
+	}
+}
+
+// halt requests the program to stop without holding the lock, as Delve
+// only returns once the running command finished.
+func (v *View) halt() {
+	v.halted.Store(true)
+	if err := v.dbg.Halt(); err != nil {
+		v.mu.Lock()
+		v.status.SetError(err)
+		v.mu.Unlock()
+	}
+}
+
+// Stopped reports whether the program is stopped and can be inspected or
+// modified. If it is running, a hint is shown.
+func (v *View) Stopped() bool {
+	if v.running {
+		v.status.SetError(errRunning)
+		return false
+	}
+	if v.dbg.Exited() {
+		v.status.SetError(errExited)
+		return false
+	}
+	return true
+}
+
+// Restart rebuilds the program if godbg built it and runs it to the
+// initial breakpoints again. Breakpoints, watch expressions and expanded
+// variables are kept.
+func (v *View) Restart() {
+	if v.dbg.ReadOnly() {
+		v.status.SetError(errReadOnly)
+		return
+	}
+	if v.running {
+		v.status.SetError(errRunning)
+		return
+	}
+
+	v.exitOpen = false
+	v.run(func() error {
+		discarded, err := v.dbg.Restart()
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.source.InitBreakpoints(v.dbg)
+		var buildErr *build.Error
+		if errors.As(err, &buildErr) {
+			v.buildErrors = strings.Split(strings.ReplaceAll(buildErr.Output, "\t", "    "), "\n")
+			return errors.New("build failed, the program was not restarted")
+		}
+		if err != nil {
+			return err
+		}
+
+		// The sources may have been edited before the rebuild.
+		v.source.Reload()
+		v.files.PreviewCache.Purge()
+		if len(discarded) > 0 {
+			msgs := make([]string, len(discarded))
+			for i, bp := range discarded {
+				msgs[i] = fmt.Sprintf("breakpoint %s:%d removed: %s", filepath.Base(bp.Breakpoint.File), bp.Breakpoint.Line, bp.Reason)
+			}
+			return errors.New(strings.Join(msgs, "; "))
+		}
+		return nil
+	})
+}
+
+// SwitchGoroutine selects the goroutine delta positions away from the
+// currently selected one.
+func (v *View) SwitchGoroutine(delta int) {
+	if !v.Stopped() {
+		return
+	}
+	gs, err := v.dbg.Goroutines()
+	if err != nil {
+		v.status.SetError(err)
+		return
+	}
+	if len(gs) == 0 {
+		return
+	}
+
+	cur := 0
+	for i, g := range gs {
+		if g.ID == v.dbg.GoroutineID() {
+			cur = i
+			break
+		}
+	}
+	v.SelectGoroutine(gs[(cur+delta+len(gs))%len(gs)].ID)
+}
+
+// SelectGoroutine shows the goroutine with the given id in the Source and
+// Variables panes. Stepping continues with this goroutine.
+func (v *View) SelectGoroutine(id int64) {
+	if !v.Stopped() {
+		return
+	}
+	if err := v.dbg.SelectGoroutine(id); err != nil {
+		v.status.SetError(err)
+		return
+	}
+	v.Update()
+}
+
+// SelectFrame shows the frame with the given index of the selected
+// goroutine's stack in the Source and Variables panes.
+func (v *View) SelectFrame(frame int) {
+	if !v.Stopped() {
+		return
+	}
+	if err := v.dbg.SelectFrame(frame); err != nil {
+		v.status.SetError(err)
+		return
+	}
+	v.Update()
+}
+
+// MoveFrame selects the frame delta positions towards the callers.
+func (v *View) MoveFrame(delta int) {
+	if !v.Stopped() {
+		return
+	}
+	frame := v.dbg.Frame() + delta
+	if frame < 0 {
+		return
+	}
+	v.SelectFrame(frame)
+}
+
 func (v *View) Paint() {
 	p := perf.Start("Paint")
 	text := frame.New(v.height, v.width)
@@ -227,24 +1320,102 @@ func (v *View) Paint() {
 	colors := frame.New(v.height, v.width)
 	p.Mark("Color Frame")
 
-	for i := 0; i < v.height; i++ {
+	for i := 0; i < v.height-1; i++ {
 		colors.SetColor(i, v.source.Size.Width, 1, frame.ColorFGBlack)
 		text.WriteAt(i, v.source.Size.Width, '│')
 	}
 	p.Mark("Render VBar")
 
-	v.source.RenderFrame(text, colors, 0, 0)
-	p.Mark("Render Source")
+	if v.disasmOpen {
+		v.disasm.RenderFrame(text, colors, 0, 0)
+		p.Mark("Render Disassembly")
+	} else {
+		v.source.RenderFrame(text, colors, 0, 0)
+		p.Mark("Render Source")
+	}
+
+	if v.outputOpen {
+		v.output.Load(v.dbg.Output().Lines())
+		v.output.RenderFrame(text, colors, v.source.Size.Height, 0)
+		p.Mark("Render Output")
+	}
+	if v.logsOpen {
+		v.logs.Load(logLines(v.dbg.Logs().Entries()))
+		v.logs.RenderFrame(text, colors, v.logsY(), 0)
+		p.Mark("Render Logs")
+	}
 
 	v.variables.RenderFrame(text, colors, 0, v.source.Size.Width+1)
 	p.Mark("Render Variables")
 
+	if v.crashOpen {
+		v.crash.RenderFrame(text, colors, v.variables.Size.Height, v.source.Size.Width+1)
+		p.Mark("Render Crash")
+	}
+	if v.goroutinesOpen {
+		v.goroutines.RenderFrame(text, colors, v.goroutinesY(), v.source.Size.Width+1)
+		p.Mark("Render Goroutines")
+	}
+	if v.stackOpen {
+		v.stack.RenderFrame(text, colors, v.goroutinesY()+v.goroutinesHeight(), v.source.Size.Width+1)
+		p.Mark("Render Stack")
+	}
+	if v.registersOpen {
+		v.registers.RenderFrame(text, colors, v.goroutinesY()+v.goroutinesHeight()+v.stackHeight(), v.source.Size.Width+1)
+		p.Mark("Render Registers")
+	}
+	if v.breakpointsOpen {
+		v.breakpoints.Load(v.source.Breakpoints)
+		v.breakpoints.RenderFrame(text, colors, v.goroutinesY()+v.goroutinesHeight()+v.stackHeight()+v.registersHeight(), v.source.Size.Width+1)
+		p.Mark("Render Breakpoints")
+	}
+
+	if v.inputMode {
+		colors.SetColor(v.height-1, 0, min(len(inputLabel), v.width), frame.ColorFGYellow)
+		x := text.WriteString(v.height-1, 0, inputLabel)
+		v.input.RenderFrame(text, colors, v.height-1, x+1, v.width-x-1)
+	} else if v.promptOpen {
+		v.prompt.RenderFrame(text, colors, v.height-1, 1, v.width-1)
+	} else {
+		v.status.RenderFrame(text, colors, v.height-1, 0)
+	}
+	p.Mark("Render Status")
+
 	filesY, filesX := 3, 16
 	if v.filesOpen {
 		colors.Fill(frame.ColorFGBlack)
 		v.files.RenderFrame(text, colors, filesY, filesX)
 		p.Mark("Render Files")
 	}
+	if v.funcsOpen {
+		colors.Fill(frame.ColorFGBlack)
+		v.funcs.RenderFrame(text, colors, filesY, filesX)
+		p.Mark("Render Functions")
+	}
+
+	if v.quitOpen {
+		colors.Fill(frame.ColorFGBlack)
+		dialog := quitDialog()
+		size := dialog.Size()
+		dialog.RenderFrame(text, colors, max(0, (v.height-size.Height)/2), max(0, (v.width-size.Width)/2))
+		p.Mark("Render Quit")
+	}
+
+	if v.exitOpen {
+		colors.Fill(frame.ColorFGBlack)
+		dialog := v.exitDialog()
+		size := dialog.Size()
+		dialog.RenderFrame(text, colors, max(0, (v.height-size.Height)/2), max(0, (v.width-size.Width)/2))
+		p.Mark("Render Exit")
+	}
+
+	if v.buildErrors != nil {
+		colors.Fill(frame.ColorFGBlack)
+		dialog := v.buildDialog()
+		size := dialog.Size()
+		dialog.RenderFrame(text, colors, max(0, (v.height-size.Height)/2), max(0, (v.width-size.Width)/2))
+		p.Mark("Render Build")
+	}
 
 	out := v.tty.Output()
 	out.Write(term.HideCursor)
@@ -255,30 +1426,463 @@ func (v *View) Paint() {
 		cy, cx := v.files.CursorPosition()
 		out.Write(term.ShowCursor)
 		out.Write(term.PositionCursor(cy+filesY, cx+filesX))
+	} else if v.funcsOpen {
+		cy, cx := v.funcs.CursorPosition()
+		out.Write(term.ShowCursor)
+		out.Write(term.PositionCursor(cy+filesY, cx+filesX))
+	} else if v.inputMode {
+		x := min(len(inputLabel), v.width) + 1
+		out.Write(term.ShowCursor)
+		out.Write(term.PositionCursor(v.height, x+v.input.CursorPosition(v.width-x)+1))
+	} else if v.promptOpen {
+		out.Write(term.ShowCursor)
+		out.Write(term.PositionCursor(v.height, v.prompt.CursorPosition(v.width-1)+2))
+	} else if v.outputOpen && v.output.Searching {
+		cy, cx := v.output.CursorPosition()
+		out.Write(term.ShowCursor)
+		out.Write(term.PositionCursor(cy+v.source.Size.Height+1, cx+1))
+	} else if v.variables.Editing {
+		cy, cx := v.variables.EditorPosition()
+		out.Write(term.ShowCursor)
+		out.Write(term.PositionCursor(cy+1, cx+v.source.Size.Width+2))
+	} else if v.goroutinesOpen && v.goroutines.Filtering {
+		cy, cx := v.goroutines.CursorPosition()
+		out.Write(term.ShowCursor)
+		out.Write(term.PositionCursor(cy+v.goroutinesY()+1, cx+v.source.Size.Width+2))
+	} else if v.logsOpen && v.logs.Searching {
+		cy, cx := v.logs.CursorPosition()
+		out.Write(term.ShowCursor)
+		out.Write(term.PositionCursor(cy+v.logsY()+1, cx+1))
 	}
 
 	p.Mark("Print Output")
 	p.End()
 }
 
+// Quit reports whether the UI should exit right away. For attached
+// processes it asks whether to detach or kill the target first.
+func (v *View) Quit() bool {
+	if v.running {
+		v.quitAfterHalt = true
+		go v.halt()
+		return false
+	}
+	if !v.dbg.Attached() {
+		return true
+	}
+	v.quitOpen = true
+	return false
+}
+
+// saveBeforeDetach saves the session while the breakpoints can still be
+// listed.
+func (v *View) saveBeforeDetach() {
+	if err := v.SaveSession(); err != nil {
+		debug.Logf("save session: %v", err)
+	}
+}
+
+func quitDialog() *Dialog {
+	return &Dialog{
+		Title: "Quit",
+		Lines: []string{
+			"d    detach and leave process running",
+			"k    kill process",
+			"esc  cancel",
+		},
+	}
+}
+
+// exitLines is the number of output lines shown when the program exited.
+const exitLines = 8
+
+func (v *View) exitDialog() *Dialog {
+	lines := []string{fmt.Sprintf("exit status %d", v.dbg.ExitStatus()), ""}
+	out := v.dbg.Output().Lines()
+	if len(out) > 0 {
+		for _, l := range out[max(0, len(out)-exitLines):] {
+			lines = append(lines, "  "+strings.ReplaceAll(l.Text, "\t", "    "))
+		}
+		lines = append(lines, "")
+	}
+	return &Dialog{
+		Title: "Exited",
+		Lines: append(lines,
+			"r    restart",
+			"q    quit",
+			"esc  close",
+		),
+	}
+}
+
+func (v *View) buildDialog() *Dialog {
+	// The dialog is cut off at the bottom of the screen, so the hint to
+	// close it is kept visible.
+	lines := v.buildErrors[:min(len(v.buildErrors), max(0, v.height-6))]
+	return &Dialog{
+		Title: "Build failed",
+		Lines: append(lines[:len(lines):len(lines)], "", "esc  close"),
+	}
+}
+
 func (v *View) UpdateFocus() {
 	v.source.Focused = v.focus == PaneSource
+	v.disasm.Focused = v.focus == PaneSource
 	v.variables.Focused = v.focus == PaneVariables
+	v.output.Focused = v.focus == PaneOutput
+	v.logs.Focused = v.focus == PaneLogs
+	v.goroutines.Focused = v.focus == PaneGoroutines
+	v.stack.Focused = v.focus == PaneStack
+	v.registers.Focused = v.focus == PaneRegisters
+	v.crash.Focused = v.focus == PaneCrash
+	v.breakpoints.Focused = v.focus == PaneBreakpoints
+}
+
+// NextFocus moves the focus to the next open pane.
+func (v *View) NextFocus() {
+	for {
+		v.focus = (v.focus + 1) % PaneCount
+		if v.focus == PaneOutput && !v.outputOpen ||
+			v.focus == PaneLogs && !v.logsOpen ||
+			v.focus == PaneGoroutines && !v.goroutinesOpen ||
+			v.focus == PaneStack && !v.stackOpen ||
+			v.focus == PaneRegisters && !v.registersOpen ||
+			v.focus == PaneCrash && !v.crashOpen ||
+			v.focus == PaneBreakpoints && !v.breakpointsOpen {
+			continue
+		}
+		break
+	}
+	v.UpdateFocus()
+}
+
+func (v *View) ToggleOutput() {
+	v.outputOpen = !v.outputOpen
+	if v.outputOpen {
+		v.output.Load(v.dbg.Output().Lines())
+		v.output.MoveBottom()
+		v.focus = PaneOutput
+	} else if v.focus == PaneOutput {
+		v.focus = PaneSource
+	}
+	v.UpdateFocus()
+	v.Resize(v.width, v.height)
+}
+
+func (v *View) ToggleLogs() {
+	v.logsOpen = !v.logsOpen
+	if v.logsOpen {
+		v.logs.Load(logLines(v.dbg.Logs().Entries()))
+		v.logs.MoveBottom()
+		v.focus = PaneLogs
+	} else if v.focus == PaneLogs {
+		v.focus = PaneSource
+	}
+	v.UpdateFocus()
+	v.Resize(v.width, v.height)
+}
+
+func (v *View) ToggleGoroutines() {
+	if !v.goroutinesOpen && !v.Stopped() {
+		return
+	}
+	v.goroutinesOpen = !v.goroutinesOpen
+	if v.goroutinesOpen {
+		v.LoadGoroutines()
+		v.focus = PaneGoroutines
+	} else if v.focus == PaneGoroutines {
+		v.focus = PaneSource
+	}
+	v.UpdateFocus()
+	v.Resize(v.width, v.height)
+}
+
+func (v *View) LoadGoroutines() {
+	gs, err := v.dbg.Goroutines()
+	if err != nil {
+		v.status.SetError(err)
+	}
+	v.goroutines.Load(gs, v.dbg.GoroutineID())
+}
+
+func (v *View) ToggleStack() {
+	if !v.stackOpen && !v.Stopped() {
+		return
+	}
+	v.stackOpen = !v.stackOpen
+	if v.stackOpen {
+		v.LoadStack()
+		v.focus = PaneStack
+	} else if v.focus == PaneStack {
+		v.focus = PaneSource
+	}
+	v.UpdateFocus()
+	v.Resize(v.width, v.height)
+}
+
+// ToggleDisassembly switches between the source and the disassembly of
+// the selected frame's function.
+func (v *View) ToggleDisassembly() {
+	if !v.disasmOpen && !v.Stopped() {
+		return
+	}
+	v.disasmOpen = !v.disasmOpen
+	v.disasmAuto = false
+	if v.disasmOpen {
+		v.LoadDisassembly()
+	}
+}
+
+func (v *View) LoadDisassembly() {
+	insts, err := v.dbg.Disassemble()
+	if err != nil {
+		v.status.SetError(err)
+	}
+	v.disasm.Load(insts)
+	v.disasm.CallerFrame = v.dbg.Frame() != 0
+}
+
+// ToggleInstructionBreakpoint toggles the breakpoint on the source line
+// of the instruction under the cursor.
+func (v *View) ToggleInstructionBreakpoint() {
+	if !v.Stopped() {
+		return
+	}
+	inst, ok := v.disasm.Selected()
+	if !ok || inst.Loc.File == "" {
+		return
+	}
+
+	var err error
+	if bp := breakpointAt(v.dbg.Breakpoints(), inst.Loc.File, inst.Loc.Line); bp != nil {
+		err = v.dbg.ClearBreakpoint(bp.ID)
+	} else {
+		_, err = v.dbg.CreateFileBreakpoint(inst.Loc.File, inst.Loc.Line)
+	}
+	if err != nil {
+		v.status.SetError(err)
+	}
+	v.source.InitBreakpoints(v.dbg)
+
+	cursor, start := v.disasm.LineCursor, v.disasm.LineStart
+	v.LoadDisassembly()
+	v.disasm.LineCursor, v.disasm.LineStart = cursor, start
+	v.disasm.AlignCursor()
+}
+
+func (v *View) LoadStack() {
+	frames, err := v.dbg.Stacktrace()
+	if err != nil {
+		v.status.SetError(err)
+	}
+	v.stack.Load(frames, v.dbg.Frame())
+}
+
+func (v *View) ToggleRegisters() {
+	if !v.registersOpen && !v.Stopped() {
+		return
+	}
+	v.registersOpen = !v.registersOpen
+	if v.registersOpen {
+		v.LoadRegisters()
+		v.focus = PaneRegisters
+	} else if v.focus == PaneRegisters {
+		v.focus = PaneSource
+	}
+	v.UpdateFocus()
+	v.Resize(v.width, v.height)
+}
+
+func (v *View) LoadRegisters() {
+	regs, err := v.dbg.Registers(v.registers.FloatingPoint)
+	if err != nil {
+		v.status.SetError(err)
+	}
+	v.registers.Load(regs, v.dbg.GoroutineID(), v.dbg.Frame(), v.stops)
+}
+
+func (v *View) ToggleBreakpoints() {
+	if !v.breakpointsOpen && !v.Stopped() {
+		return
+	}
+	v.breakpointsOpen = !v.breakpointsOpen
+	if v.breakpointsOpen {
+		v.source.InitBreakpoints(v.dbg)
+		v.focus = PaneBreakpoints
+	} else if v.focus == PaneBreakpoints {
+		v.focus = PaneSource
+	}
+	v.UpdateFocus()
+	v.Resize(v.width, v.height)
+}
+
+// ToggleBreakpointEnabled enables or disables the breakpoint under the
+// cursor of the breakpoints pane.
+func (v *View) ToggleBreakpointEnabled() {
+	if !v.Stopped() {
+		return
+	}
+	bp, ok := v.breakpoints.Selected()
+	if !ok {
+		return
+	}
+	if err := v.dbg.SetBreakpointEnabled(bp.ID, bp.Disabled); err != nil {
+		v.status.SetError(err)
+	}
+	v.source.InitBreakpoints(v.dbg)
+}
+
+// DeleteBreakpoints clears the given breakpoints.
+func (v *View) DeleteBreakpoints(bps ...*api.Breakpoint) {
+	if !v.Stopped() {
+		return
+	}
+	for _, bp := range bps {
+		if err := v.dbg.ClearBreakpoint(bp.ID); err != nil {
+			v.status.SetError(err)
+		}
+	}
+	v.source.InitBreakpoints(v.dbg)
+}
+
+// JumpToBreakpoint shows the location of the breakpoint under the cursor
+// in the source pane.
+func (v *View) JumpToBreakpoint() {
+	bp, ok := v.breakpoints.Selected()
+	if !ok || bp.WatchExpr != "" || bp.Line == 0 {
+		return
+	}
+
+	var pcFile string
+	var pcLine int
+	if !v.running {
+		pcFile, pcLine = v.dbg.Location()
+	}
+	v.source.LoadLocation(bp.File, bp.Line)
+	v.source.Cursors.PC = -1
+	if pcFile == bp.File {
+		v.source.Cursors.PC = pcLine - 1
+	}
+
+	v.disasmOpen, v.disasmAuto = false, false
+	v.focus = PaneSource
+	v.UpdateFocus()
+}
+
+// ShowCrash opens the crash pane with the panic value and the stack of
+// the crashing goroutine. The user frame that crashed is selected.
+func (v *View) ShowCrash(crash *dlv.Crash) {
+	v.crashOpen = true
+	v.stackOpen = true
+	v.Resize(v.width, v.height)
+	v.crash.Load(crash)
+	v.UpdateFocus()
+}
+
+// goroutinesY returns the row of the goroutines pane, which is below the
+// crash pane if it is open.
+func (v *View) goroutinesY() int {
+	if v.crashOpen {
+		return v.variables.Size.Height + v.crash.Size.Height
+	}
+	return v.variables.Size.Height
+}
+
+func (v *View) goroutinesHeight() int {
+	if v.goroutinesOpen {
+		return v.goroutines.Size.Height
+	}
+	return 0
+}
+
+func (v *View) stackHeight() int {
+	if v.stackOpen {
+		return v.stack.Size.Height
+	}
+	return 0
+}
+
+func (v *View) registersHeight() int {
+	if v.registersOpen {
+		return v.registers.Size.Height
+	}
+	return 0
+}
+
+// logsY returns the row of the logs pane, which is below the output pane
+// if both are open.
+func (v *View) logsY() int {
+	if v.outputOpen {
+		return v.source.Size.Height + v.output.Size.Height
+	}
+	return v.source.Size.Height
 }
 
 func (v *View) Resize(width, height int) {
 	v.width = width
 	v.height = height
 
-	v.source.Resize(width*5/7, height)
-	v.variables.Resize(width-1-v.source.Size.Width, height)
+	v.source.Resize(width*5/7, height-1)
+	v.variables.Resize(width-1-v.source.Size.Width, height-1)
+	// The crash, goroutines, stack, registers and breakpoints panes share
+	// the lower half of the right column.
+	var lower []interface{ Resize(w, h int) }
+	if v.crashOpen {
+		lower = append(lower, &v.crash)
+	}
+	if v.goroutinesOpen {
+		lower = append(lower, &v.goroutines)
+	}
+	if v.stackOpen {
+		lower = append(lower, &v.stack)
+	}
+	if v.registersOpen {
+		lower = append(lower, &v.registers)
+	}
+	if v.breakpointsOpen {
+		lower = append(lower, &v.breakpoints)
+	}
+	if len(lower) > 0 {
+		lowerHeight := (height - 1) / 2
+		v.variables.Resize(v.variables.Size.Width, height-1-lowerHeight)
+		for i, pane := range lower {
+			h := lowerHeight / len(lower)
+			if i == len(lower)-1 {
+				h = lowerHeight - h*(len(lower)-1)
+			}
+			pane.Resize(v.variables.Size.Width, h)
+		}
+	}
+	if v.outputOpen || v.logsOpen {
+		bottomHeight := (height - 1) / 3
+		if v.outputOpen && v.logsOpen {
+			bottomHeight = (height - 1) / 2
+		}
+		v.source.Resize(v.source.Size.Width, height-1-bottomHeight)
+
+		switch {
+		case v.outputOpen && v.logsOpen:
+			v.output.Resize(v.source.Size.Width, bottomHeight/2)
+			v.logs.Resize(v.source.Size.Width, bottomHeight-bottomHeight/2)
+		case v.outputOpen:
+			v.output.Resize(v.source.Size.Width, bottomHeight)
+		default:
+			v.logs.Resize(v.source.Size.Width, bottomHeight)
+		}
+	}
+	v.source.AlignCursor()
+	v.disasm.Resize(v.source.Size.Width, v.source.Size.Height)
+	v.status.Resize(width, 1)
 	v.files.Resize(width-32, height-6)
+	v.funcs.Resize(width-32, height-6)
 }
 
 func (v *View) ResizeLoop() {
 	for size := range v.tty.SIGWINCH() {
+		v.mu.Lock()
 		v.Resize(size.W, size.H)
 		v.Paint()
+		v.mu.Unlock()
 	}
 }
 
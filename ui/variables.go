@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/go-delve/delve/service/api"
 	"github.com/philippta/godbg/frame"
@@ -14,6 +16,10 @@ type Variables struct {
 	Size       Size
 	Variables  []Variable
 	Expanded   map[string]bool
+	Returned   []api.Variable
+	Watches    []string
+	Editing    bool
+	Editor     Prompt
 	NumVisible int
 	LineCursor int
 	LineStart  int
@@ -23,12 +29,41 @@ func (v *Variables) Resize(w, h int) {
 	v.Size.Width, v.Size.Height = w, h
 }
 
-func (v *Variables) Load(vars []api.Variable) {
-	v.Variables = flattenVariables(fillValues(vars))
+// Load shows the returned values and the values of the watch expressions
+// above the variables. Watches that failed to evaluate carry the error as
+// Unreadable.
+func (v *Variables) Load(watches, vars []api.Variable) {
+	v.Variables = flattenVariables(v.Returned)
+	for i := range v.Variables {
+		v.Variables[i].Returned = true
+	}
+	n := len(v.Variables)
+	v.Variables = append(v.Variables, flattenVariables(fillValues(watches))...)
+	for i := n; i < len(v.Variables); i++ {
+		v.Variables[i].Watch = true
+	}
+	v.Variables = append(v.Variables, flattenVariables(fillValues(vars))...)
 	v.NumVisible = visibleVariables(v.Variables, v.Expanded)
 	v.AlignCursor()
 }
 
+// SetReturned shows the values returned by a call or a step out as an
+// expanded group named label. Unnamed results are numbered.
+func (v *Variables) SetReturned(label string, vals []api.Variable) {
+	for i := range vals {
+		if strings.HasPrefix(vals[i].Name, "~") {
+			vals[i].Name = "#" + strconv.Itoa(i)
+		}
+	}
+	group := api.Variable{Name: label, Kind: reflect.Struct, Children: vals}
+	v.Returned = fillValues([]api.Variable{group})
+
+	if v.Expanded == nil {
+		v.Expanded = map[string]bool{}
+	}
+	v.Expanded[pathKey([]string{label}, false)] = true
+}
+
 func (v *Variables) MoveUp() {
 	v.LineCursor = max(0, v.LineCursor-1)
 	v.AlignCursor()
@@ -73,6 +108,84 @@ func (v *Variables) Collapse() {
 	v.AlignCursor()
 }
 
+// Selected returns the variable under the cursor.
+func (v *Variables) Selected() (Variable, bool) {
+	count := 0
+	for _, va := range v.Variables {
+		if !isVariableVisible(va, v.Expanded) {
+			continue
+		}
+		if count == v.LineCursor {
+			return va, true
+		}
+		count++
+	}
+	return Variable{}, false
+}
+
+func (v *Variables) AddWatch(expr string) {
+	v.Watches = append(v.Watches, expr)
+}
+
+// RemoveWatch removes the watch expression under the cursor and reports
+// whether there was one.
+func (v *Variables) RemoveWatch() bool {
+	va, ok := v.Selected()
+	if !ok || !va.Watch {
+		return false
+	}
+	for i, w := range v.Watches {
+		if w == va.Path[0] {
+			v.Watches = append(v.Watches[:i], v.Watches[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// StartEdit opens the inline editor for the value of the variable under
+// the cursor. It returns why the variable cannot be edited instead.
+func (v *Variables) StartEdit() error {
+	va, ok := v.Selected()
+	if !ok {
+		return nil
+	}
+	if err := editError(va); err != nil {
+		return err
+	}
+	v.Editing = true
+	v.Editor.Reset("", va.Value)
+	return nil
+}
+
+// EditorPosition returns the position of the editor's cursor relative to
+// the pane.
+func (v *Variables) EditorPosition() (y, x int) {
+	va, _ := v.Selected()
+	x = 3 + va.Depth*2 + len(va.Name) + 3
+	return v.LineCursor - v.LineStart, x + v.Editor.CursorPosition(v.Size.Width-x)
+}
+
+// editError returns why the value of a variable cannot be assigned, or
+// nil if Delve may accept a new value.
+func editError(va Variable) error {
+	switch {
+	case va.Returned:
+		return fmt.Errorf("%s cannot be edited: returned values are copies", va.Name)
+	case va.Unreadable != "":
+		return fmt.Errorf("%s cannot be edited: %s", va.Name, va.Unreadable)
+	case va.Kind == reflect.Struct:
+		return fmt.Errorf("%s cannot be edited: structs are edited field by field", va.Name)
+	case va.Kind == reflect.Array:
+		return fmt.Errorf("%s cannot be edited: arrays are edited element by element", va.Name)
+	case va.Kind == reflect.Map:
+		return fmt.Errorf("%s cannot be edited: Delve cannot assign maps", va.Name)
+	case len(va.Path) > 1 && isMapKey(va.Path[len(va.Path)-1]):
+		return fmt.Errorf("%s cannot be edited: Delve cannot assign map elements", va.Name)
+	}
+	return nil
+}
+
 func (v *Variables) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int) {
 	var linenum int
 	for _, va := range v.Variables {
@@ -103,14 +216,28 @@ func (v *Variables) RenderFrame(text, colors *frame.Frame, offsetY, offsetX int)
 
 		x = x + va.Depth*2
 
-		colors.SetColor(y, x, len(va.Name), frame.ColorFGBlue)
+		if va.Returned && va.Depth == 0 {
+			colors.SetColor(y, x, len(va.Name), frame.ColorFGGreen)
+		} else if va.Watch && va.Depth == 0 {
+			colors.SetColor(y, x, len(va.Name), frame.ColorFGYellow)
+		} else {
+			colors.SetColor(y, x, len(va.Name), frame.ColorFGBlue)
+		}
 		x = text.WriteString(y, x, va.Name)
 
 		if linenum == v.LineCursor && v.Focused {
 			colors.SetColor(y, x, v.Size.Width-x, frame.ColorFGWhite)
 		}
 		x = text.WriteString(y, x, " = ")
-		x = text.WriteString(y, x, va.Value)
+		if v.Editing && linenum == v.LineCursor {
+			v.Editor.RenderFrame(text, colors, y, x, offsetX+v.Size.Width-x)
+		} else if va.Unreadable != "" {
+			msg := "<" + va.Unreadable + ">"
+			colors.SetColor(y, x, len(msg), frame.ColorFGRed)
+			x = text.WriteString(y, x, msg)
+		} else {
+			x = text.WriteString(y, x, va.Value)
+		}
 
 		if x-offsetX+len(va.Type) <= v.Size.Width {
 			text.WriteString(y, offsetX+v.Size.Width-len(va.Type), va.Type)
@@ -129,6 +256,12 @@ type Variable struct {
 	Path     []string
 	Depth    int
 	HasChild bool
+	// Watch is set for watch expressions and their children.
+	Watch bool
+	// Returned is set for values returned by a call or a step out and
+	// their children.
+	Returned   bool
+	Unreadable string
 }
 
 var globsb strings.Builder
@@ -269,17 +402,19 @@ func flattenVariables(vars []api.Variable) []Variable {
 	var flatten func(v api.Variable, path []string, depth int)
 	flatten = func(v api.Variable, path []string, depth int) {
 		flat = append(flat, Variable{
-			Name:     v.Name,
-			Type:     v.Type,
-			Value:    v.Value,
-			Kind:     v.Kind,
-			Path:     path,
-			Depth:    depth,
-			HasChild: len(v.Children) > 0,
+			Name:       v.Name,
+			Type:       v.Type,
+			Value:      v.Value,
+			Kind:       v.Kind,
+			Path:       path,
+			Depth:      depth,
+			HasChild:   len(v.Children) > 0,
+			Unreadable: v.Unreadable,
 		})
 
 		for _, child := range v.Children {
-			childPath := append(path, child.Name)
+			// Siblings must not share the backing array of their path.
+			childPath := append(path[:len(path):len(path)], child.Name)
 			flatten(child, childPath, depth+1)
 		}
 	}
@@ -291,6 +426,40 @@ func flattenVariables(vars []api.Variable) []Variable {
 	return flat
 }
 
+// variableExpr builds a Go expression for the variable at path, such as
+// "items[0].Name" or "(*p).Count".
+func variableExpr(path []string) string {
+	expr := path[0]
+	for _, name := range path[1:] {
+		switch {
+		case name == "*":
+			expr = "(*" + expr + ")"
+		case strings.HasPrefix(name, "["):
+			expr += name
+		case isMapKey(name):
+			expr += "[" + name + "]"
+		default:
+			expr += "." + name
+		}
+	}
+	return expr
+}
+
+// isMapKey reports whether a path element is the key of a map entry
+// rather than a field, index or dereference.
+func isMapKey(name string) bool {
+	return name != "*" && !strings.HasPrefix(name, "[") && !isIdentifier(name)
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
 func visibleVariables(vars []Variable, exp map[string]bool) int {
 	var sum int
 	for _, v := range vars {
@@ -301,8 +470,16 @@ func visibleVariables(vars []Variable, exp map[string]bool) int {
 	return sum
 }
 
-func pathKey(path []string) string {
-	return strings.Join(path, ".")
+// pathKey identifies the variable at path in the expanded set. Watch
+// expressions get their own prefix, as the key of the watch s.items
+// would otherwise be the one of the field items of a variable s. No
+// expression starts with "watch:".
+func pathKey(path []string, watch bool) string {
+	key := strings.Join(path, ".")
+	if watch {
+		return "watch:" + key
+	}
+	return key
 }
 
 func isVariableVisible(v Variable, exp map[string]bool) bool {
@@ -312,7 +489,7 @@ func isVariableVisible(v Variable, exp map[string]bool) bool {
 
 	for i := 1; i < len(v.Path); i++ {
 		parentPath := v.Path[:i]
-		if !exp[pathKey(parentPath)] {
+		if !exp[pathKey(parentPath, v.Watch)] {
 			return false
 		}
 	}
@@ -329,7 +506,7 @@ func expandVariable(vars []Variable, cursor int, exp map[string]bool) {
 
 		if count == cursor {
 			if v.HasChild {
-				exp[pathKey(v.Path)] = true
+				exp[pathKey(v.Path, v.Watch)] = true
 			}
 			break
 		}
@@ -345,13 +522,13 @@ func collapseVariable(vars []Variable, cursor *int, exp map[string]bool) {
 		}
 
 		if count == *cursor {
-			currPathKey := pathKey(v.Path)
+			currPathKey := pathKey(v.Path, v.Watch)
 
 			if _, ok := exp[currPathKey]; ok {
 				delete(exp, currPathKey)
 			} else {
 				parentPath := v.Path[:max(len(v.Path)-1, 1)]
-				parentPathKey := pathKey(parentPath)
+				parentPathKey := pathKey(parentPath, v.Watch)
 
 				delete(exp, parentPathKey)
 
@@ -360,7 +537,7 @@ func collapseVariable(vars []Variable, cursor *int, exp map[string]bool) {
 					if !isVariableVisible(w, exp) {
 						continue
 					}
-					if len(w.Path) == len(parentPath) && pathKey(w.Path) == parentPathKey {
+					if len(w.Path) == len(parentPath) && pathKey(w.Path, w.Watch) == parentPathKey {
 						break
 					}
 					newcursor++
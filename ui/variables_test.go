@@ -4,9 +4,11 @@ import (
 	_ "embed"
 	"encoding/json"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/go-delve/delve/service/api"
+	"github.com/philippta/godbg/frame"
 )
 
 //go:embed testdata/vars.json
@@ -24,7 +26,7 @@ func TestFlattenVariables(t *testing.T) {
 	linenum := 0
 
 	for _, f := range flat {
-		exp[pathKey(f.Path)] = true
+		exp[pathKey(f.Path, f.Watch)] = true
 	}
 
 	v := Variables{
@@ -36,6 +38,89 @@ func TestFlattenVariables(t *testing.T) {
 		LineCursor: linenum,
 	}
 
-	text, colors := v.RenderFrame()
+	text, colors := frame.New(30, 90), frame.New(30, 90)
+	text.FillSpace()
+	v.RenderFrame(text, colors, 0, 0)
 	text.PrintLinesColored(os.Stdout, colors)
 }
+
+func TestFlattenNestedPaths(t *testing.T) {
+	field := func(name, value string) api.Variable {
+		return api.Variable{Name: name, Value: value}
+	}
+	vars := []api.Variable{{
+		Name: "s",
+		Children: []api.Variable{{
+			Name: "items",
+			Children: []api.Variable{{
+				Name:     "[0]",
+				Children: []api.Variable{field("Name", `"a"`), field("Count", "1"), field("Total", "2")},
+			}},
+		}},
+	}}
+
+	want := map[string][]string{
+		`"a"`: {"s", "items", "[0]", "Name"},
+		"1":   {"s", "items", "[0]", "Count"},
+		"2":   {"s", "items", "[0]", "Total"},
+	}
+	exprs := map[string]string{
+		`"a"`: "s.items[0].Name",
+		"1":   "s.items[0].Count",
+		"2":   "s.items[0].Total",
+	}
+	for _, v := range flattenVariables(vars) {
+		path, ok := want[v.Value]
+		if !ok {
+			continue
+		}
+		if !slices.Equal(v.Path, path) {
+			t.Errorf("path of %s = %v, want %v", v.Name, v.Path, path)
+		}
+		if expr := variableExpr(v.Path); expr != exprs[v.Value] {
+			t.Errorf("expression of %s = %s, want %s", v.Name, expr, exprs[v.Value])
+		}
+	}
+}
+
+func TestVariableExpr(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"total"}, "total"},
+		{[]string{"s", "items", "[1]", "Count"}, "s.items[1].Count"},
+		{[]string{"p", "*", "Count"}, "(*p).Count"},
+		{[]string{"m", `"key"`, "Count"}, `m["key"].Count`},
+	}
+	for _, tt := range tests {
+		if got := variableExpr(tt.path); got != tt.want {
+			t.Errorf("variableExpr(%v) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExpandWatchSeparately(t *testing.T) {
+	watches := []api.Variable{{
+		Name:     "s.items",
+		Children: []api.Variable{{Name: "[0]", Value: "1"}},
+	}}
+	vars := []api.Variable{{
+		Name: "s",
+		Children: []api.Variable{{
+			Name:     "items",
+			Children: []api.Variable{{Name: "[0]", Value: "1"}},
+		}},
+	}}
+	v := Variables{Size: Size{Width: 40, Height: 10}}
+	v.Load(watches, vars)
+
+	// The watch s.items and the field items of s must not expand together.
+	v.LineCursor = 1
+	v.Expand()
+	v.LineCursor = 0
+	v.Expand()
+	if v.NumVisible != 4 {
+		t.Errorf("visible variables = %d, want 4", v.NumVisible)
+	}
+}